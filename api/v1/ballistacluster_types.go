@@ -18,6 +18,7 @@ package v1
 
 import (
 	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -37,6 +38,58 @@ type BallistaClusterSpec struct {
 
 	// Executor is the executor specification.
 	Executor ExecutorSpec `json:"executor"`
+
+	// ExecutorPools are groups of executors next to those of Executor, each with its own PodSpec and size, e.g.
+	// high-memory executors on dedicated nodes and cheap ones on spot nodes. The pools share the ports,
+	// configuration and decommissioning settings of Executor, and are not autoscaled.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	ExecutorPools []ExecutorPool `json:"executorPools,omitempty"`
+
+	// UpgradeStrategy controls how running pods are replaced when the pod templates change, e.g. on a new
+	// BallistaVersion or Image.
+	// +optional
+	UpgradeStrategy UpgradeStrategy `json:"upgradeStrategy,omitempty"`
+
+	// WhenReadyTimeoutSeconds is how long a restart or termination requested with RequestedOperationAnnotation
+	// waits for the running jobs to finish before it proceeds anyway. Default to 3600.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	WhenReadyTimeoutSeconds *int32 `json:"whenReadyTimeoutSeconds,omitempty"`
+
+	// IdleTimeoutSeconds is how long the scheduler may run no jobs before the cluster is suspended: its
+	// executors are scaled to zero while the scheduler stays up. The executors come back once a job is
+	// submitted, or on request with ResumeOperation. Clusters are never suspended if it is not set.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	IdleTimeoutSeconds *int32 `json:"idleTimeoutSeconds,omitempty"`
+
+	// TTLSecondsAfterIdle makes the cluster ephemeral: once its scheduler ran no jobs for this long, the
+	// cluster is terminated the way TerminateOperation does, then deleted after TTLSecondsAfterFinished.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	TTLSecondsAfterIdle *int32 `json:"ttlSecondsAfterIdle,omitempty"`
+
+	// TTLSecondsAfterFinished makes the cluster ephemeral: once the cluster is terminated, for whatever reason,
+	// it is deleted after this long. Default to 0, that is right away, when only TTLSecondsAfterIdle is set.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+}
+
+// UpgradeStrategy controls a rolling upgrade of the cluster. The scheduler is replaced first, then the
+// executors in batches, each batch waiting for the previous one to become ready.
+type UpgradeStrategy struct {
+	// ExecutorBatchSize is the number of executors replaced at a time. Default to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	ExecutorBatchSize *int32 `json:"executorBatchSize,omitempty"`
+	// ReadinessTimeoutSeconds is how long a replaced pod has to become ready before the upgrade halts.
+	// Default to 300.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	ReadinessTimeoutSeconds *int32 `json:"readinessTimeoutSeconds,omitempty"`
 }
 
 // SchedulerSpec is specification of the scheduler.
@@ -53,7 +106,8 @@ type SchedulerSpec struct {
 	// +optional
 	Lifecycle *apiv1.Lifecycle `json:"lifecycle,omitempty"`
 	// KubernetesMaster is the URL of the Kubernetes master used by the scheduler to manage executor pods and
-	// other Kubernetes resources. Default to https://kubernetes.default.svc.
+	// other Kubernetes resources, passed to the scheduler container in the KUBERNETES_MASTER environment
+	// variable unless the container sets it. Default to https://kubernetes.default.svc.
 	// +optional
 	KubernetesMaster *string `json:"kubernetesMaster,omitempty"`
 	// ServiceAnnotations defines the annotations to be added to the Kubernetes headless service used by
@@ -63,18 +117,165 @@ type SchedulerSpec struct {
 	// Ports settings for the pods, following the Kubernetes specifications.
 	// +optional
 	Ports []Port `json:"ports,omitempty"`
+	// Config holds the tuning settings of the scheduler.
+	// +optional
+	Config *SchedulerConfig `json:"config,omitempty"`
+	// Properties are extra settings of the scheduler, by name in its configuration file. Settings of Config
+	// and StateBackend take precedence.
+	// +optional
+	Properties map[string]string `json:"properties,omitempty"`
+	// StateBackend is where the scheduler keeps the state of the cluster, among which its job history.
+	// Default to the memory of the scheduler.
+	// +optional
+	StateBackend *StateBackend `json:"stateBackend,omitempty"`
+	// Replicas is the number of scheduler pods. More than one replica shares the state in etcd, and requires
+	// the Etcd state backend; the replicas are spread across nodes and serve behind the scheduler service once
+	// ready. Default to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Replicas *int32 `json:"replicas,omitempty"`
 }
 
 // ExecutorSpec is specification of the executor.
 type ExecutorSpec struct {
 	apiv1.PodSpec `json:",inline"`
-	// Instances is the number of executor instances.
+	// Instances is the number of executor instances. Zero leaves the work to the ExecutorPools. Default to 1,
+	// or to 0 when the cluster has ExecutorPools.
 	// +optional
-	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Minimum=0
 	Instances *int32 `json:"instances,omitempty"`
 	// Ports settings for the pods, following the Kubernetes specifications.
 	// +optional
 	Ports []Port `json:"ports,omitempty"`
+	// DecommissionGracePeriodSeconds is how long an executor removed on scale-down is kept, once the
+	// scheduler was told to stop assigning it tasks, for the jobs it ran tasks of to finish, before it is
+	// deleted. An executor the scheduler cannot be told of is deleted when its grace period is over.
+	// Default to 300.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	DecommissionGracePeriodSeconds *int32 `json:"decommissionGracePeriodSeconds,omitempty"`
+	// Autoscaling sizes the executors after the task queue of the scheduler. Instances is then only the
+	// number of executors the cluster starts with.
+	// +optional
+	Autoscaling *ExecutorAutoscaling `json:"autoscaling,omitempty"`
+	// Config holds the tuning settings of the executors.
+	// +optional
+	Config *ExecutorConfig `json:"config,omitempty"`
+	// Properties are extra settings of the executors, by name in their configuration file. Settings of
+	// Config take precedence.
+	// +optional
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// ExecutorPool is a named group of executors with their own PodSpec, reconciled on its own. The PodSpec
+// carries the node selector, affinity and tolerations that place the executors of the pool.
+type ExecutorPool struct {
+	// Name is the name of the pool, unique in the cluster. The executor pods of the pool are named after it
+	// and labeled with it.
+	// +kubebuilder:validation:MaxLength=32
+	// +kubebuilder:validation:Pattern=`^[a-z]([-a-z0-9]*[a-z0-9])?$`
+	Name          string `json:"name"`
+	apiv1.PodSpec `json:",inline"`
+	// Instances is the number of executors of the pool. Default to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Instances *int32 `json:"instances,omitempty"`
+	// Labels are added to the executor pods of the pool, e.g. to tell them apart in cost reports.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// Fallback moves the executors of the pool to another pool, e.g. one of on-demand nodes, while too many of
+	// them are preempted.
+	// +optional
+	Fallback *ExecutorPoolFallback `json:"fallback,omitempty"`
+}
+
+// ExecutorPoolFallback moves the executors of a pool to another pool once Preemptions of its executors were
+// preempted within WindowSeconds, until the window passes without that many preemptions.
+type ExecutorPoolFallback struct {
+	// PoolName is the name of the pool taking over the executors, another pool of the cluster without a
+	// fallback of its own.
+	PoolName string `json:"poolName"`
+	// Preemptions is the number of preemptions within the window that moves the executors.
+	// +kubebuilder:validation:Minimum=1
+	Preemptions int32 `json:"preemptions"`
+	// WindowSeconds is the time window preemptions are counted over. Default to 600.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	WindowSeconds *int32 `json:"windowSeconds,omitempty"`
+}
+
+// ExecutorAutoscaling keeps as many executors as are running tasks, plus one for every
+// TargetPendingTasksPerExecutor tasks waiting in the queue of the scheduler, within MinInstances and
+// MaxInstances.
+type ExecutorAutoscaling struct {
+	// MinInstances is the least number of executors. Default to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MinInstances *int32 `json:"minInstances,omitempty"`
+	// MaxInstances is the largest number of executors.
+	// +kubebuilder:validation:Minimum=1
+	MaxInstances int32 `json:"maxInstances"`
+	// TargetPendingTasksPerExecutor is the number of pending tasks that warrant an extra executor. Default to 4.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	TargetPendingTasksPerExecutor *int32 `json:"targetPendingTasksPerExecutor,omitempty"`
+	// ScaleUpCooldownSeconds is how long to wait after scaling before adding executors. Default to 30.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	ScaleUpCooldownSeconds *int32 `json:"scaleUpCooldownSeconds,omitempty"`
+	// ScaleDownCooldownSeconds is how long to wait after scaling before removing executors. Default to 300.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	ScaleDownCooldownSeconds *int32 `json:"scaleDownCooldownSeconds,omitempty"`
+}
+
+// StateBackendType is the kind of store the scheduler keeps the state of the cluster in.
+// +kubebuilder:validation:Enum=Memory;Sled;Etcd
+type StateBackendType string
+
+const (
+	// MemoryStateBackend keeps the state in the memory of the scheduler, which loses it when it restarts.
+	MemoryStateBackend StateBackendType = "Memory"
+	// SledStateBackend keeps the state in a Sled database on a volume that survives restarts of the scheduler.
+	SledStateBackend StateBackendType = "Sled"
+	// EtcdStateBackend keeps the state in an external etcd cluster.
+	EtcdStateBackend StateBackendType = "Etcd"
+)
+
+// StateBackend selects the store the scheduler keeps the state of the cluster in.
+type StateBackend struct {
+	// Type is the kind of store. Default to Memory.
+	// +optional
+	Type StateBackendType `json:"type,omitempty"`
+	// Sled configures the Sled store, and may only be set when Type is Sled.
+	// +optional
+	Sled *SledBackend `json:"sled,omitempty"`
+	// Etcd configures the etcd store, and is required when Type is Etcd.
+	// +optional
+	Etcd *EtcdBackend `json:"etcd,omitempty"`
+}
+
+// SledBackend keeps the state in a Sled database on a PersistentVolumeClaim the operator provisions for the
+// cluster. The claim outlives restarts of the cluster, and is deleted along with it. It cannot change once
+// the cluster exists.
+type SledBackend struct {
+	// StorageClassName is the storage class of the claim. Default to the default storage class.
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+	// Size is the requested size of the volume. Default to 1Gi.
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
+}
+
+// EtcdBackend keeps the state in an external etcd cluster.
+type EtcdBackend struct {
+	// Endpoints are the URLs of the etcd members, e.g. https://etcd-0.etcd:2379.
+	// +kubebuilder:validation:MinItems=1
+	Endpoints []string `json:"endpoints"`
+	// TLSSecretName is the name of a Secret in the namespace of the cluster holding the CA certificate
+	// (ca.crt), and the client certificate (tls.crt) and key (tls.key), the scheduler reaches etcd with.
+	// +optional
+	TLSSecretName *string `json:"tlsSecretName,omitempty"`
 }
 
 // Port represents the port definition in the pods objects.
@@ -84,14 +285,136 @@ type Port struct {
 	ContainerPort int32  `json:"containerPort"`
 }
 
+// LogLevel is the verbosity of the Ballista logs.
+// +kubebuilder:validation:Enum=error;warn;info;debug;trace
+type LogLevel string
+
+// SchedulerConfig holds the tuning settings of the scheduler. The operator renders them, along with the
+// Properties of the scheduler, into a ConfigMap the scheduler reads its configuration file from.
+type SchedulerConfig struct {
+	// LogLevel is the verbosity of the scheduler logs.
+	// +optional
+	LogLevel *LogLevel `json:"logLevel,omitempty"`
+	// ShufflePartitions is the default number of partitions of the shuffles of the jobs.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	ShufflePartitions *int32 `json:"shufflePartitions,omitempty"`
+	// BatchSize is the default number of rows in the record batches of the jobs.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	BatchSize *int32 `json:"batchSize,omitempty"`
+}
+
+// ExecutorConfig holds the tuning settings of the executors. The operator renders them, along with the
+// Properties of the executors, into a ConfigMap the executors read their configuration file from.
+type ExecutorConfig struct {
+	// LogLevel is the verbosity of the executor logs.
+	// +optional
+	LogLevel *LogLevel `json:"logLevel,omitempty"`
+	// ConcurrentTasks is the number of tasks an executor runs at a time.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	ConcurrentTasks *int32 `json:"concurrentTasks,omitempty"`
+	// WorkDir is the directory executors write shuffle files to.
+	// +optional
+	WorkDir *string `json:"workDir,omitempty"`
+}
 
+const (
+	// RequestedOperationAnnotation requests an operation on the cluster once it is idle, that is once its
+	// scheduler runs no jobs or WhenReadyTimeoutSeconds elapsed. The operator removes the annotation when it
+	// accepts the request.
+	RequestedOperationAnnotation = "ballista.minzhou.info/requested-operation"
+	// RestartOperation restarts the cluster once it is idle.
+	RestartOperation = "restart"
+	// TerminateOperation terminates the cluster once it is idle. Restarting a terminated cluster brings it back.
+	TerminateOperation = "terminate"
+	// ResumeOperation brings back the executors of a cluster suspended after IdleTimeoutSeconds, right away.
+	ResumeOperation = "resume"
+)
+
+const (
+	// DefaultSchedulerPort is the port the Ballista scheduler serves gRPC on.
+	DefaultSchedulerPort int32 = 50050
+	// DefaultExecutorPort is the port the Ballista executor serves Arrow Flight on.
+	DefaultExecutorPort int32 = 50051
+)
 
 // BallistaClusterStatus defines the observed state of BallistaCluster
 type BallistaClusterStatus struct {
-	ClusterID string `json:"clusterId,omitempty"`
-	ClusterState ClusterState `json:"clusterState,omitempty"`
+	// ObservedGeneration is the generation of the spec the status was last reconciled against.
+	ObservedGeneration int64        `json:"observedGeneration,omitempty"`
+	ClusterID          string       `json:"clusterId,omitempty"`
+	ClusterState       ClusterState `json:"clusterState,omitempty"`
+	// SchedulerState records the state of the scheduler: running as long as one of its replicas runs.
+	SchedulerState SchedulerState `json:"schedulerState,omitempty"`
+	// Schedulers reports the state of every scheduler replica.
+	// +optional
+	Schedulers []SchedulerReplicaStatus `json:"schedulers,omitempty"`
+	// ReadySchedulers is the number of scheduler replicas that are ready to serve.
+	ReadySchedulers int32 `json:"readySchedulers"`
 	// ExecutorState records the state of executors by executor Pod names.
 	ExecutorState map[string]ExecutorState `json:"executorState,omitempty"`
+	// ReadyExecutors is the number of executors of Spec.Executor that are running with all their containers
+	// ready.
+	ReadyExecutors int32 `json:"readyExecutors"`
+	// PendingExecutors is the number of executors of Spec.Executor that are scheduled or starting.
+	PendingExecutors int32 `json:"pendingExecutors"`
+	// FailedExecutors is the number of executors of Spec.Executor that failed or cannot start.
+	FailedExecutors int32 `json:"failedExecutors"`
+	// Executors is the number of executor pods of Spec.Executor that are neither terminated, decommissioning
+	// nor being deleted. It is the replica count of the scale subresource.
+	Executors int32 `json:"executors"`
+	// DesiredExecutors is the number of executors Spec.Executor is sized to, following the autoscaler and
+	// idle suspension when enabled.
+	DesiredExecutors int32 `json:"desiredExecutors"`
+	// ExecutorPools reports the executors of every pool of Spec.ExecutorPools, which the counts of the
+	// cluster leave out.
+	// +optional
+	ExecutorPools []ExecutorPoolStatus `json:"executorPools,omitempty"`
+	// Preemptions is the number of executors of the cluster lost to the preemption of their node or to eviction.
+	// +optional
+	Preemptions int32 `json:"preemptions,omitempty"`
+	// RecentPreemptions are the preemptions of the executors of pools with a fallback, within the fallback
+	// window of their pool.
+	// +optional
+	RecentPreemptions []ExecutorPreemption `json:"recentPreemptions,omitempty"`
+	// ExecutorSelector is the label selector of the executor pods of Spec.Executor, in string form, for the
+	// scale subresource.
+	ExecutorSelector string `json:"executorSelector,omitempty"`
+	// Autoscaling reports the decisions of the executor autoscaler.
+	Autoscaling *AutoscalingStatus `json:"autoscaling,omitempty"`
+	// CurrentVersion is the Ballista version every pod of the cluster runs.
+	CurrentVersion string `json:"currentVersion,omitempty"`
+	// Upgrade reports the progress of the latest rolling upgrade.
+	Upgrade *UpgradeStatus `json:"upgrade,omitempty"`
+	// IdleSince is the time the scheduler was first seen running no jobs, when IdleTimeoutSeconds is set.
+	IdleSince *metav1.Time `json:"idleSince,omitempty"`
+	// Conditions are the latest observations of the cluster.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+	// WhenReadySince is the time the cluster was asked to restart or terminate once idle.
+	WhenReadySince *metav1.Time `json:"whenReadySince,omitempty"`
+	// TerminationTime is the time the cluster was terminated, while it is.
+	TerminationTime *metav1.Time `json:"terminationTime,omitempty"`
+	// SchedulerEndpoint is the stable DNS name and port of the scheduler service, e.g.
+	// my-cluster-scheduler.default.svc:50050.
+	SchedulerEndpoint string `json:"schedulerEndpoint,omitempty"`
+}
+
+// SchedulerReplicaStatus is the observed state of a scheduler replica.
+type SchedulerReplicaStatus struct {
+	// Name is the name of the scheduler pod.
+	Name string `json:"name"`
+	// State is the state of the scheduler pod.
+	State SchedulerState `json:"state"`
+	// Ready tells whether the scheduler pod is ready to serve.
+	Ready bool `json:"ready"`
+	// Message explains why a replica failed.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // ClusterStateType represents the type of the current state of an application.
@@ -105,11 +428,11 @@ const (
 	RestartWhenReadyState ClusterStateType = "RESTART_WHEN_READY"
 	Restarting            ClusterStateType = "RESTARTING"
 
-	TerminateWhenReady    ClusterStateType = "TERMINATE_WHEN_READY"
-	Terminating           ClusterStateType = "TERMINATING"
-	Terminated            ClusterStateType = "TERMINATED"
+	TerminateWhenReady ClusterStateType = "TERMINATE_WHEN_READY"
+	Terminating        ClusterStateType = "TERMINATING"
+	Terminated         ClusterStateType = "TERMINATED"
 
-	UnknownState          ClusterStateType = "UNKNOWN"
+	UnknownState ClusterStateType = "UNKNOWN"
 )
 
 // ClusterState tells the current state of the application and an error message in case of failures.
@@ -118,6 +441,36 @@ type ClusterState struct {
 	ErrorMessage string           `json:"errorMessage,omitempty"`
 }
 
+// ExecutorPoolStatus reports the executors of a pool.
+type ExecutorPoolStatus struct {
+	// Name is the name of the pool.
+	Name string `json:"name"`
+	// DesiredExecutors is the number of executors the pool is sized to.
+	DesiredExecutors int32 `json:"desiredExecutors"`
+	// Executors is the number of executor pods of the pool that are neither terminated, decommissioning nor
+	// being deleted.
+	Executors int32 `json:"executors"`
+	// ReadyExecutors is the number of executors of the pool that are running with all their containers ready.
+	ReadyExecutors int32 `json:"readyExecutors"`
+	// PendingExecutors is the number of executors of the pool that are scheduled or starting.
+	PendingExecutors int32 `json:"pendingExecutors"`
+	// FailedExecutors is the number of executors of the pool that failed or cannot start.
+	FailedExecutors int32 `json:"failedExecutors"`
+	// FallbackActive tells whether the executors of the pool moved to its fallback pool.
+	// +optional
+	FallbackActive bool `json:"fallbackActive,omitempty"`
+}
+
+// ExecutorPreemption records the preemption of an executor.
+type ExecutorPreemption struct {
+	// Pool is the name of the executor pool of the executor.
+	Pool string `json:"pool"`
+	// Executor is the name of the executor pod.
+	Executor string `json:"executor"`
+	// Time is when the preemption was noticed.
+	Time metav1.Time `json:"time"`
+}
+
 // SchedulerState tells the current state of a ballista scheduler.
 type SchedulerState string
 
@@ -130,6 +483,107 @@ const (
 	SchedulerUnknownState   SchedulerState = "UNKNOWN"
 )
 
+// Types and reasons of the conditions of a cluster.
+const (
+	// SchedulerReadyCondition is true while the scheduler pod runs.
+	SchedulerReadyCondition = "SchedulerReady"
+	// ExecutorsReadyCondition is true while the desired number of executors are ready.
+	ExecutorsReadyCondition = "ExecutorsReady"
+	// AvailableCondition is true while the cluster runs with its scheduler and all its executors ready.
+	AvailableCondition = "Available"
+	// ProgressingCondition is true while the cluster starts, restarts, terminates, upgrades or scales.
+	ProgressingCondition = "Progressing"
+	// DegradedCondition is true while the cluster fails to reach its desired state.
+	DegradedCondition = "Degraded"
+	// SuspendedCondition is true while the executors of an idle cluster are scaled to zero.
+	SuspendedCondition = "Suspended"
+
+	// SchedulerRunningReason is the reason of a running scheduler.
+	SchedulerRunningReason = "SchedulerRunning"
+	// SchedulerPendingReason is the reason of a scheduler that is starting or being replaced.
+	SchedulerPendingReason = "SchedulerPending"
+	// SchedulerFailedReason is the reason of a scheduler that failed or completed.
+	SchedulerFailedReason = "SchedulerFailed"
+	// SchedulerUnknownReason is the reason of a scheduler that cannot be observed.
+	SchedulerUnknownReason = "SchedulerUnknown"
+	// ExecutorsRunningReason is the reason of executors that are all ready.
+	ExecutorsRunningReason = "ExecutorsRunning"
+	// ExecutorsPendingReason is the reason of executors that are not all ready yet.
+	ExecutorsPendingReason = "ExecutorsPending"
+	// ExecutorsFailedReason is the reason of executors that failed or cannot start.
+	ExecutorsFailedReason = "ExecutorsFailed"
+	// ClusterRunningReason is the reason of a cluster that runs as desired.
+	ClusterRunningReason = "ClusterRunning"
+	// ClusterNotRunningReason is the reason of a cluster that does not run, named after its state in the message.
+	ClusterNotRunningReason = "ClusterNotRunning"
+	// StartingReason is the reason of a cluster that is starting.
+	StartingReason = "Starting"
+	// RestartingReason is the reason of a cluster that is restarting.
+	RestartingReason = "Restarting"
+	// TerminatingReason is the reason of a cluster that is terminating.
+	TerminatingReason = "Terminating"
+	// UpgradingReason is the reason of a cluster in the middle of a rolling upgrade.
+	UpgradingReason = "Upgrading"
+	// ScalingReason is the reason of a cluster whose executors are being added or removed.
+	ScalingReason = "Scaling"
+	// StableReason is the reason of a cluster that is not changing.
+	StableReason = "Stable"
+	// ReconcileErrorReason is the reason of a cluster the operator failed to reconcile.
+	ReconcileErrorReason = "ReconcileError"
+	// UpgradeFailedReason is the reason of a cluster whose rolling upgrade halted.
+	UpgradeFailedReason = "UpgradeFailed"
+	// AsExpectedReason is the reason of a cluster that is not degraded.
+	AsExpectedReason = "AsExpected"
+
+	// IdleTimeoutReason is the reason of a cluster suspended after IdleTimeoutSeconds without jobs.
+	IdleTimeoutReason = "IdleTimeout"
+	// JobSubmittedReason is the reason of a cluster resumed as a job was submitted.
+	JobSubmittedReason = "JobSubmitted"
+	// ResumeRequestedReason is the reason of a cluster resumed on request.
+	ResumeRequestedReason = "ResumeRequested"
+	// IdleTimeoutDisabledReason is the reason of a cluster resumed as IdleTimeoutSeconds was unset.
+	IdleTimeoutDisabledReason = "IdleTimeoutDisabled"
+)
+
+// UpgradePhase tells how far a rolling upgrade has come.
+type UpgradePhase string
+
+// Different phases a rolling upgrade may be in.
+const (
+	UpgradingSchedulerPhase UpgradePhase = "UPGRADING_SCHEDULER"
+	UpgradingExecutorsPhase UpgradePhase = "UPGRADING_EXECUTORS"
+	UpgradeCompletedPhase   UpgradePhase = "COMPLETED"
+	UpgradeFailedPhase      UpgradePhase = "FAILED"
+)
+
+// UpgradeStatus reports the progress of a rolling upgrade.
+type UpgradeStatus struct {
+	// Phase is the phase the upgrade is in.
+	Phase UpgradePhase `json:"phase"`
+	// TargetVersion is the Ballista version the cluster is upgrading to.
+	TargetVersion string `json:"targetVersion,omitempty"`
+	// TemplateHash identifies the scheduler and executor pod templates the cluster is upgrading to.
+	TemplateHash string `json:"templateHash,omitempty"`
+	// UpdatedExecutors is the number of executors running the target pod template.
+	UpdatedExecutors int32 `json:"updatedExecutors"`
+	// StartTime is the time the upgrade started.
+	StartTime metav1.Time `json:"startTime,omitempty"`
+	// Message explains why the upgrade failed.
+	Message string `json:"message,omitempty"`
+}
+
+// AutoscalingStatus reports the decisions of the executor autoscaler.
+type AutoscalingStatus struct {
+	// DesiredExecutors is the number of executors the autoscaler asks for.
+	DesiredExecutors int32 `json:"desiredExecutors"`
+	// PendingTasks is the number of tasks waiting in the queue of the scheduler at the last poll.
+	PendingTasks int32 `json:"pendingTasks"`
+	// ActiveJobs is the number of jobs queued or running at the last poll.
+	ActiveJobs int32 `json:"activeJobs"`
+	// LastScaleTime is the last time the autoscaler changed the number of executors.
+	// +optional
+	LastScaleTime *metav1.Time `json:"lastScaleTime,omitempty"`
+}
 
 // ExecutorState tells the current state of an executor.
 type ExecutorState string
@@ -141,10 +595,21 @@ const (
 	ExecutorCompletedState ExecutorState = "COMPLETED"
 	ExecutorFailedState    ExecutorState = "FAILED"
 	ExecutorUnknownState   ExecutorState = "UNKNOWN"
+
+	// ExecutorDecommissioningState is the state of an executor finishing its tasks before it is removed.
+	ExecutorDecommissioningState ExecutorState = "DECOMMISSIONING"
 )
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:subresource:scale:specpath=.spec.executor.instances,statuspath=.status.executors,selectorpath=.status.executorSelector
+//+kubebuilder:resource:shortName=bc,categories=ballista
+//+kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.spec.ballistaVersion`
+//+kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.clusterState.state`
+//+kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=`.status.readyExecutors`,description="Number of ready executors"
+//+kubebuilder:printcolumn:name="Desired",type=integer,JSONPath=`.status.desiredExecutors`,description="Number of desired executors"
+//+kubebuilder:printcolumn:name="Endpoint",type=string,JSONPath=`.status.schedulerEndpoint`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // BallistaCluster is the Schema for the ballistaclusters API
 // BallistaCluster represents a Ballista cluster running on and using Kubernetes as a cluster manager.
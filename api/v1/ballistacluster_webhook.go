@@ -17,7 +17,18 @@ limitations under the License.
 package v1
 
 import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/version"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -38,16 +49,204 @@ func (r *BallistaCluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
 
 var _ webhook.Defaulter = &BallistaCluster{}
 
+const (
+	// DefaultImageName is the name of the Ballista image used when Image is not set. The tag is the Ballista version.
+	DefaultImageName = "ballista"
+	// DefaultKubernetesMaster is the Kubernetes master the scheduler uses when KubernetesMaster is not set.
+	DefaultKubernetesMaster = "https://kubernetes.default.svc"
+	// ManagedImageAnnotation records the image last derived from Image and BallistaVersion. Containers still
+	// running that image follow the cluster to a new version or image.
+	ManagedImageAnnotation = "ballista.minzhou.info/managed-image"
+
+	// DefaultExecutorBatchSize is the number of executors replaced at a time during an upgrade.
+	DefaultExecutorBatchSize int32 = 1
+	// DefaultReadinessTimeoutSeconds is how long a replaced pod has to become ready during an upgrade.
+	DefaultReadinessTimeoutSeconds int32 = 300
+	// DefaultDecommissionGracePeriodSeconds is how long an executor removed on scale-down may finish its tasks.
+	DefaultDecommissionGracePeriodSeconds int32 = 300
+	// DefaultAutoscalingMinInstances is the least number of executors an autoscaled cluster keeps.
+	DefaultAutoscalingMinInstances int32 = 1
+	// DefaultExecutorPoolInstances is the number of executors of an executor pool.
+	DefaultExecutorPoolInstances int32 = 1
+	// DefaultPreemptionWindowSeconds is the time window the preemptions of the executors of a pool are counted
+	// over to fall back to another pool.
+	DefaultPreemptionWindowSeconds int32 = 600
+	// DefaultTargetPendingTasksPerExecutor is the number of pending tasks that warrant an extra executor.
+	DefaultTargetPendingTasksPerExecutor int32 = 4
+	// DefaultScaleUpCooldownSeconds is how long the autoscaler waits after scaling before adding executors.
+	DefaultScaleUpCooldownSeconds int32 = 30
+	// DefaultScaleDownCooldownSeconds is how long the autoscaler waits after scaling before removing executors.
+	DefaultScaleDownCooldownSeconds int32 = 300
+	// DefaultWhenReadyTimeoutSeconds is how long a requested restart or termination waits for running jobs.
+	DefaultWhenReadyTimeoutSeconds int32 = 3600
+	// DefaultSchedulerReplicas is the number of scheduler pods of a cluster.
+	DefaultSchedulerReplicas int32 = 1
+	// DefaultSledVolumeSize is the size of the volume of the Sled state backend.
+	DefaultSledVolumeSize = "1Gi"
+
+	// SchedulerContainerName is the name of the scheduler container.
+	SchedulerContainerName = "scheduler"
+	// ExecutorContainerName is the name of the executor container.
+	ExecutorContainerName = "executor"
+	// SchedulerPortName is the name of the scheduler gRPC port.
+	SchedulerPortName = "scheduler"
+	// ExecutorPortName is the name of the executor Arrow Flight port.
+	ExecutorPortName = "flight"
+)
+
+var (
+	// schedulerCommand and executorCommand start the Ballista processes in the Ballista image.
+	schedulerCommand = []string{"/scheduler"}
+	executorCommand  = []string{"/executor"}
+
+	defaultSchedulerRequests = apiv1.ResourceList{
+		apiv1.ResourceCPU:    resource.MustParse("500m"),
+		apiv1.ResourceMemory: resource.MustParse("512Mi"),
+	}
+	defaultExecutorRequests = apiv1.ResourceList{
+		apiv1.ResourceCPU:    resource.MustParse("1"),
+		apiv1.ResourceMemory: resource.MustParse("1Gi"),
+	}
+)
+
 // Default implements webhook.Defaulter so a webhook will be registered for the type
 func (r *BallistaCluster) Default() {
 	ballistaclusterlog.Info("default", "name", r.Name)
 
+	image := r.defaultImage()
+	managedImage := r.Annotations[ManagedImageAnnotation]
+	if r.Annotations == nil {
+		r.Annotations = make(map[string]string)
+	}
+	r.Annotations[ManagedImageAnnotation] = image
+
+	scheduler := &r.Spec.Scheduler
+	if scheduler.KubernetesMaster == nil {
+		master := DefaultKubernetesMaster
+		scheduler.KubernetesMaster = &master
+	}
+	if len(scheduler.Ports) == 0 {
+		scheduler.Ports = []Port{{Name: SchedulerPortName, ContainerPort: DefaultSchedulerPort}}
+	}
+	defaultPorts(scheduler.Ports)
+	defaultPodSpec(&scheduler.PodSpec, SchedulerContainerName, image, managedImage, schedulerCommand, defaultSchedulerRequests)
+	defaultInt32(&scheduler.Replicas, DefaultSchedulerReplicas)
+	if backend := scheduler.StateBackend; backend != nil {
+		if backend.Type == "" {
+			backend.Type = MemoryStateBackend
+		}
+		if backend.Type == SledStateBackend {
+			if backend.Sled == nil {
+				backend.Sled = &SledBackend{}
+			}
+			if backend.Sled.Size == nil {
+				size := resource.MustParse(DefaultSledVolumeSize)
+				backend.Sled.Size = &size
+			}
+		}
+	}
+
+	executor := &r.Spec.Executor
+	if executor.Instances == nil && len(r.Spec.ExecutorPools) == 0 {
+		instances := int32(1)
+		executor.Instances = &instances
+	}
+	if len(executor.Ports) == 0 {
+		executor.Ports = []Port{{Name: ExecutorPortName, ContainerPort: DefaultExecutorPort}}
+	}
+	defaultPorts(executor.Ports)
+	if executor.DecommissionGracePeriodSeconds == nil {
+		gracePeriod := DefaultDecommissionGracePeriodSeconds
+		executor.DecommissionGracePeriodSeconds = &gracePeriod
+	}
+	if autoscaling := executor.Autoscaling; autoscaling != nil {
+		defaultInt32(&autoscaling.MinInstances, DefaultAutoscalingMinInstances)
+		defaultInt32(&autoscaling.TargetPendingTasksPerExecutor, DefaultTargetPendingTasksPerExecutor)
+		defaultInt32(&autoscaling.ScaleUpCooldownSeconds, DefaultScaleUpCooldownSeconds)
+		defaultInt32(&autoscaling.ScaleDownCooldownSeconds, DefaultScaleDownCooldownSeconds)
+	}
+	defaultPodSpec(&executor.PodSpec, ExecutorContainerName, image, managedImage, executorCommand, defaultExecutorRequests)
+	for i := range r.Spec.ExecutorPools {
+		pool := &r.Spec.ExecutorPools[i]
+		defaultInt32(&pool.Instances, DefaultExecutorPoolInstances)
+		if pool.Fallback != nil {
+			defaultInt32(&pool.Fallback.WindowSeconds, DefaultPreemptionWindowSeconds)
+		}
+		defaultPodSpec(&pool.PodSpec, ExecutorContainerName, image, managedImage, executorCommand, defaultExecutorRequests)
+	}
 
-	// TODO(mzhou): scheduler defaults and executor defaults
+	upgrade := &r.Spec.UpgradeStrategy
+	if upgrade.ExecutorBatchSize == nil {
+		batchSize := DefaultExecutorBatchSize
+		upgrade.ExecutorBatchSize = &batchSize
+	}
+	if upgrade.ReadinessTimeoutSeconds == nil {
+		timeout := DefaultReadinessTimeoutSeconds
+		upgrade.ReadinessTimeoutSeconds = &timeout
+	}
 
+	if r.Spec.WhenReadyTimeoutSeconds == nil {
+		timeout := DefaultWhenReadyTimeoutSeconds
+		r.Spec.WhenReadyTimeoutSeconds = &timeout
+	}
+}
+
+// defaultImage returns the image of the Ballista containers: Image, tagged with BallistaVersion unless it
+// carries a tag or digest already, or the default Ballista image of that version.
+func (r *BallistaCluster) defaultImage() string {
+	if r.Spec.Image == nil || *r.Spec.Image == "" {
+		return fmt.Sprintf("%s:%s", DefaultImageName, r.Spec.BallistaVersion)
+	}
+	image := *r.Spec.Image
+	// a colon after the last slash separates the tag, a colon before it the registry port
+	if strings.Contains(image, "@") || strings.Contains(image[strings.LastIndex(image, "/")+1:], ":") {
+		return image
+	}
+	return fmt.Sprintf("%s:%s", image, r.Spec.BallistaVersion)
+}
+
+// defaultInt32 sets the field to the value unless it is set already.
+func defaultInt32(field **int32, value int32) {
+	if *field == nil {
+		*field = &value
+	}
+}
+
+// defaultPorts defaults the protocol of the ports to TCP.
+func defaultPorts(ports []Port) {
+	for i := range ports {
+		if ports[i].Protocol == "" {
+			ports[i].Protocol = string(apiv1.ProtocolTCP)
+		}
+	}
+}
+
+// defaultPodSpec makes sure the pod spec has a Ballista container, which is its first container, with a name,
+// an image, resource requests and, when it runs the Ballista image, the command starting Ballista. A container
+// running the previously managed image is moved to the current one.
+func defaultPodSpec(spec *apiv1.PodSpec, name string, image string, managedImage string, command []string, requests apiv1.ResourceList) {
+	if spec.RestartPolicy == "" {
+		spec.RestartPolicy = apiv1.RestartPolicyAlways
+	}
+	if len(spec.Containers) == 0 {
+		spec.Containers = []apiv1.Container{{}}
+	}
+
+	container := &spec.Containers[0]
+	if container.Name == "" {
+		container.Name = name
+	}
+	if container.Image == "" || (managedImage != "" && container.Image == managedImage) {
+		container.Image = image
+	}
+	if len(container.Command) == 0 && container.Image == image {
+		container.Command = append([]string(nil), command...)
+	}
+	if len(container.Resources.Requests) == 0 {
+		container.Resources.Requests = requests.DeepCopy()
+	}
 }
 
-// TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
 //+kubebuilder:webhook:path=/validate-ballista-minzhou-info-v1-ballistacluster,mutating=false,failurePolicy=fail,sideEffects=None,groups=ballista.minzhou.info,resources=ballistaclusters,verbs=create;update,versions=v1,name=vballistacluster.kb.io,admissionReviewVersions={v1,v1beta1}
 
 var _ webhook.Validator = &BallistaCluster{}
@@ -56,22 +255,216 @@ var _ webhook.Validator = &BallistaCluster{}
 func (r *BallistaCluster) ValidateCreate() error {
 	ballistaclusterlog.Info("validate create", "name", r.Name)
 
-	// TODO(user): fill in your validation logic upon object creation.
-	return nil
+	return r.toAggregate(r.validateBallistaCluster())
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
 func (r *BallistaCluster) ValidateUpdate(old runtime.Object) error {
 	ballistaclusterlog.Info("validate update", "name", r.Name)
 
-	// TODO(user): fill in your validation logic upon object update.
-	return nil
+	allErrs := r.validateBallistaCluster()
+	if oldCluster, ok := old.(*BallistaCluster); ok {
+		allErrs = append(allErrs, r.validateImmutableFields(oldCluster)...)
+	}
+	return r.toAggregate(allErrs)
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
 func (r *BallistaCluster) ValidateDelete() error {
 	ballistaclusterlog.Info("validate delete", "name", r.Name)
 
-	// TODO(user): fill in your validation logic upon object deletion.
 	return nil
 }
+
+// toAggregate turns the field errors into the Invalid error the API server reports, or nil if there are none.
+func (r *BallistaCluster) toAggregate(allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("BallistaCluster").GroupKind(), r.Name, allErrs)
+}
+
+// validateBallistaCluster checks the spec of the cluster.
+func (r *BallistaCluster) validateBallistaCluster() field.ErrorList {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if _, err := version.ParseSemantic(r.Spec.BallistaVersion); err != nil {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("ballistaVersion"), r.Spec.BallistaVersion, err.Error()))
+	}
+	hasImage := r.Spec.Image != nil && *r.Spec.Image != ""
+
+	schedulerPath := specPath.Child("scheduler")
+	allErrs = append(allErrs, validateContainers(&r.Spec.Scheduler.PodSpec, hasImage, schedulerPath.Child("containers"))...)
+	allErrs = append(allErrs, validatePorts(r.Spec.Scheduler.Ports, schedulerPath.Child("ports"))...)
+	if r.Spec.Scheduler.PodName != nil {
+		// the in-cluster client mode the pod name is meant for is not supported by the operator
+		allErrs = append(allErrs, field.Forbidden(schedulerPath.Child("podName"),
+			"may only be set in in-cluster-client mode, which is not supported"))
+	}
+	if r.Spec.Scheduler.StateBackend != nil {
+		allErrs = append(allErrs, validateStateBackend(r.Spec.Scheduler.StateBackend, schedulerPath.Child("stateBackend"))...)
+	}
+	if replicas := r.Spec.Scheduler.Replicas; replicas != nil && *replicas > 1 &&
+		(r.Spec.Scheduler.StateBackend == nil || r.Spec.Scheduler.StateBackend.Type != EtcdStateBackend) {
+		// the replicas only agree on the state of the cluster through etcd
+		allErrs = append(allErrs, field.Invalid(schedulerPath.Child("replicas"), *replicas,
+			"more than one replica requires the Etcd state backend"))
+	}
+
+	executorPath := specPath.Child("executor")
+	allErrs = append(allErrs, validateContainers(&r.Spec.Executor.PodSpec, hasImage, executorPath.Child("containers"))...)
+	allErrs = append(allErrs, validatePorts(r.Spec.Executor.Ports, executorPath.Child("ports"))...)
+	if autoscaling := r.Spec.Executor.Autoscaling; autoscaling != nil && autoscaling.MinInstances != nil &&
+		*autoscaling.MinInstances > autoscaling.MaxInstances {
+		allErrs = append(allErrs, field.Invalid(executorPath.Child("autoscaling", "maxInstances"), autoscaling.MaxInstances,
+			"must be greater than or equal to minInstances"))
+	}
+
+	// pools by name, telling whether they fall back to another pool
+	poolFallbacks := make(map[string]bool, len(r.Spec.ExecutorPools))
+	for i, pool := range r.Spec.ExecutorPools {
+		poolPath := specPath.Child("executorPools").Index(i)
+		if _, ok := poolFallbacks[pool.Name]; ok {
+			allErrs = append(allErrs, field.Duplicate(poolPath.Child("name"), pool.Name))
+		}
+		poolFallbacks[pool.Name] = pool.Fallback != nil
+		allErrs = append(allErrs, validateContainers(&pool.PodSpec, hasImage, poolPath.Child("containers"))...)
+	}
+	for i, pool := range r.Spec.ExecutorPools {
+		if pool.Fallback == nil {
+			continue
+		}
+		fallbackPath := specPath.Child("executorPools").Index(i).Child("fallback", "poolName")
+		hasFallback, ok := poolFallbacks[pool.Fallback.PoolName]
+		switch {
+		case pool.Fallback.PoolName == pool.Name:
+			allErrs = append(allErrs, field.Invalid(fallbackPath, pool.Fallback.PoolName, "must name another pool"))
+		case !ok:
+			allErrs = append(allErrs, field.NotFound(fallbackPath, pool.Fallback.PoolName))
+		case hasFallback:
+			// the executors only move once, which rules out chains and cycles of fallbacks
+			allErrs = append(allErrs, field.Invalid(fallbackPath, pool.Fallback.PoolName,
+				"must name a pool without a fallback"))
+		}
+	}
+
+	if operation, ok := r.Annotations[RequestedOperationAnnotation]; ok &&
+		operation != RestartOperation && operation != TerminateOperation && operation != ResumeOperation {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("metadata", "annotations").Key(RequestedOperationAnnotation),
+			operation, []string{RestartOperation, TerminateOperation, ResumeOperation}))
+	}
+
+	return allErrs
+}
+
+// validateImmutableFields rejects changes to the fields that cannot change once the cluster exists.
+func (r *BallistaCluster) validateImmutableFields(old *BallistaCluster) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if !equalStringPtr(r.Spec.Scheduler.PodName, old.Spec.Scheduler.PodName) {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "scheduler", "podName"), "field is immutable"))
+	}
+	if !apiequality.Semantic.DeepEqual(sledBackend(r.Spec.Scheduler.StateBackend), sledBackend(old.Spec.Scheduler.StateBackend)) {
+		// the claim of the Sled store is provisioned once
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "scheduler", "stateBackend", "sled"),
+			"field is immutable"))
+	}
+
+	return allErrs
+}
+
+// sledBackend returns the Sled store of the state backend, nil if it is not one.
+func sledBackend(backend *StateBackend) *SledBackend {
+	if backend == nil || backend.Type != SledStateBackend {
+		return nil
+	}
+	return backend.Sled
+}
+
+// validateStateBackend checks that the state backend configures the store of its type, and only that one.
+func validateStateBackend(backend *StateBackend, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if backend.Sled != nil && backend.Type != SledStateBackend {
+		allErrs = append(allErrs, field.Forbidden(path.Child("sled"), "may only be set when type is Sled"))
+	}
+	if backend.Type != EtcdStateBackend {
+		if backend.Etcd != nil {
+			allErrs = append(allErrs, field.Forbidden(path.Child("etcd"), "may only be set when type is Etcd"))
+		}
+		return allErrs
+	}
+
+	etcdPath := path.Child("etcd")
+	if backend.Etcd == nil || len(backend.Etcd.Endpoints) == 0 {
+		return append(allErrs, field.Required(etcdPath.Child("endpoints"), "etcd endpoints are required when type is Etcd"))
+	}
+	for i, endpoint := range backend.Etcd.Endpoints {
+		if u, err := url.Parse(endpoint); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			allErrs = append(allErrs, field.Invalid(etcdPath.Child("endpoints").Index(i), endpoint,
+				"must be an http or https URL"))
+		}
+	}
+	if secret := backend.Etcd.TLSSecretName; secret != nil {
+		for _, msg := range validation.IsDNS1123Subdomain(*secret) {
+			allErrs = append(allErrs, field.Invalid(etcdPath.Child("tlsSecretName"), *secret, msg))
+		}
+	}
+	return allErrs
+}
+
+// validateContainers makes sure a pod spec has a Ballista container, or that one can be made from the image.
+func validateContainers(spec *apiv1.PodSpec, hasImage bool, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if len(spec.Containers) == 0 && !hasImage {
+		allErrs = append(allErrs, field.Required(path, "at least one container is required when spec.image is not set"))
+	}
+	return allErrs
+}
+
+// validatePorts checks that the ports are in range, use a supported protocol, and are unique by name and by
+// port and protocol.
+func validatePorts(ports []Port, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	names := make(map[string]bool)
+	containerPorts := make(map[Port]bool)
+	for i, port := range ports {
+		portPath := path.Index(i)
+
+		if port.Name == "" {
+			allErrs = append(allErrs, field.Required(portPath.Child("name"), ""))
+		} else if names[port.Name] {
+			allErrs = append(allErrs, field.Duplicate(portPath.Child("name"), port.Name))
+		}
+		names[port.Name] = true
+
+		for _, msg := range validation.IsValidPortNum(int(port.ContainerPort)) {
+			allErrs = append(allErrs, field.Invalid(portPath.Child("containerPort"), port.ContainerPort, msg))
+		}
+
+		protocol := apiv1.Protocol(port.Protocol)
+		switch protocol {
+		case "", apiv1.ProtocolTCP, apiv1.ProtocolUDP, apiv1.ProtocolSCTP:
+		default:
+			allErrs = append(allErrs, field.NotSupported(portPath.Child("protocol"), port.Protocol,
+				[]string{string(apiv1.ProtocolTCP), string(apiv1.ProtocolUDP), string(apiv1.ProtocolSCTP)}))
+		}
+		if protocol == "" {
+			protocol = apiv1.ProtocolTCP
+		}
+
+		key := Port{Protocol: string(protocol), ContainerPort: port.ContainerPort}
+		if containerPorts[key] {
+			allErrs = append(allErrs, field.Duplicate(portPath.Child("containerPort"), port.ContainerPort))
+		}
+		containerPorts[key] = true
+	}
+	return allErrs
+}
+
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,322 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newBallistaCluster() *BallistaCluster {
+	return &BallistaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"},
+		Spec:       BallistaClusterSpec{BallistaVersion: "0.5.0"},
+	}
+}
+
+var _ = Describe("BallistaCluster webhook", func() {
+
+	Context("Default", func() {
+		It("fills in a minimal cluster", func() {
+			cluster := newBallistaCluster()
+			cluster.Default()
+
+			Expect(*cluster.Spec.Executor.Instances).To(Equal(int32(1)))
+			Expect(*cluster.Spec.Scheduler.Replicas).To(Equal(DefaultSchedulerReplicas))
+			Expect(*cluster.Spec.Scheduler.KubernetesMaster).To(Equal(DefaultKubernetesMaster))
+			Expect(cluster.Spec.Scheduler.Ports).To(Equal([]Port{{Name: SchedulerPortName, Protocol: "TCP", ContainerPort: DefaultSchedulerPort}}))
+			Expect(cluster.Spec.Executor.Ports).To(Equal([]Port{{Name: ExecutorPortName, Protocol: "TCP", ContainerPort: DefaultExecutorPort}}))
+			Expect(*cluster.Spec.WhenReadyTimeoutSeconds).To(Equal(DefaultWhenReadyTimeoutSeconds))
+			Expect(*cluster.Spec.Executor.DecommissionGracePeriodSeconds).To(Equal(DefaultDecommissionGracePeriodSeconds))
+
+			Expect(cluster.Spec.Scheduler.RestartPolicy).To(Equal(apiv1.RestartPolicyAlways))
+			Expect(cluster.Spec.Scheduler.Containers).To(HaveLen(1))
+			scheduler := cluster.Spec.Scheduler.Containers[0]
+			Expect(scheduler.Name).To(Equal(SchedulerContainerName))
+			Expect(scheduler.Image).To(Equal("ballista:0.5.0"))
+			Expect(scheduler.Command).To(Equal([]string{"/scheduler"}))
+			Expect(scheduler.Resources.Requests).NotTo(BeEmpty())
+
+			Expect(cluster.Spec.Executor.Containers).To(HaveLen(1))
+			executor := cluster.Spec.Executor.Containers[0]
+			Expect(executor.Name).To(Equal(ExecutorContainerName))
+			Expect(executor.Image).To(Equal("ballista:0.5.0"))
+			Expect(executor.Command).To(Equal([]string{"/executor"}))
+			Expect(executor.Resources.Requests).NotTo(BeEmpty())
+		})
+
+		It("keeps what the user set", func() {
+			cluster := newBallistaCluster()
+			instances := int32(3)
+			cluster.Spec.Executor.Instances = &instances
+			cluster.Spec.Executor.Containers = []apiv1.Container{{Name: "custom", Image: "custom:1", Command: []string{"/bin/run"}}}
+			cluster.Default()
+
+			Expect(*cluster.Spec.Executor.Instances).To(Equal(int32(3)))
+			executor := cluster.Spec.Executor.Containers[0]
+			Expect(executor.Name).To(Equal("custom"))
+			Expect(executor.Image).To(Equal("custom:1"))
+			Expect(executor.Command).To(Equal([]string{"/bin/run"}))
+		})
+
+		It("fills in autoscaling settings", func() {
+			cluster := newBallistaCluster()
+			cluster.Spec.Executor.Autoscaling = &ExecutorAutoscaling{MaxInstances: 10}
+			cluster.Default()
+
+			autoscaling := cluster.Spec.Executor.Autoscaling
+			Expect(*autoscaling.MinInstances).To(Equal(DefaultAutoscalingMinInstances))
+			Expect(*autoscaling.TargetPendingTasksPerExecutor).To(Equal(DefaultTargetPendingTasksPerExecutor))
+			Expect(*autoscaling.ScaleUpCooldownSeconds).To(Equal(DefaultScaleUpCooldownSeconds))
+			Expect(*autoscaling.ScaleDownCooldownSeconds).To(Equal(DefaultScaleDownCooldownSeconds))
+		})
+
+		It("fills in the Sled state backend", func() {
+			cluster := newBallistaCluster()
+			cluster.Spec.Scheduler.StateBackend = &StateBackend{Type: SledStateBackend}
+			cluster.Default()
+
+			sled := cluster.Spec.Scheduler.StateBackend.Sled
+			Expect(sled).NotTo(BeNil())
+			Expect(sled.Size.String()).To(Equal(DefaultSledVolumeSize))
+		})
+
+		It("moves managed images to a new version", func() {
+			cluster := newBallistaCluster()
+			cluster.Spec.Executor.Containers = []apiv1.Container{{Name: "executor", Image: "pinned:1"}}
+			cluster.Default()
+			Expect(cluster.Spec.Scheduler.Containers[0].Image).To(Equal("ballista:0.5.0"))
+
+			cluster.Spec.BallistaVersion = "0.6.0"
+			cluster.Default()
+			Expect(cluster.Spec.Scheduler.Containers[0].Image).To(Equal("ballista:0.6.0"))
+			Expect(cluster.Spec.Executor.Containers[0].Image).To(Equal("pinned:1"))
+
+			image := "repo/ballista"
+			cluster.Spec.Image = &image
+			cluster.Default()
+			Expect(cluster.Spec.Scheduler.Containers[0].Image).To(Equal("repo/ballista:0.6.0"))
+		})
+
+		It("fills in executor pools", func() {
+			cluster := newBallistaCluster()
+			cluster.Spec.ExecutorPools = []ExecutorPool{{Name: "spot"}}
+			cluster.Default()
+
+			// the pools run the executors of the cluster
+			Expect(cluster.Spec.Executor.Instances).To(BeNil())
+			pool := cluster.Spec.ExecutorPools[0]
+			Expect(*pool.Instances).To(Equal(DefaultExecutorPoolInstances))
+			Expect(pool.Containers).To(HaveLen(1))
+			Expect(pool.Containers[0].Name).To(Equal(ExecutorContainerName))
+			Expect(pool.Containers[0].Image).To(Equal("ballista:0.5.0"))
+			Expect(pool.Containers[0].Command).To(Equal([]string{"/executor"}))
+		})
+
+		It("is idempotent", func() {
+			cluster := newBallistaCluster()
+			cluster.Default()
+			defaulted := cluster.DeepCopy()
+			cluster.Default()
+			Expect(cluster).To(Equal(defaulted))
+		})
+	})
+
+	Context("ValidateCreate", func() {
+		It("accepts a defaulted cluster", func() {
+			cluster := newBallistaCluster()
+			cluster.Default()
+			Expect(cluster.ValidateCreate()).To(Succeed())
+		})
+
+		It("reports every invalid field", func() {
+			cluster := newBallistaCluster()
+			podName := "client"
+			cluster.Spec.BallistaVersion = "latest"
+			cluster.Spec.Scheduler.PodName = &podName
+			cluster.Spec.Scheduler.Ports = []Port{
+				{Name: "grpc", Protocol: "TCP", ContainerPort: 50050},
+				{Name: "grpc", Protocol: "HTTP", ContainerPort: 70000},
+			}
+			cluster.Spec.Executor.Ports = []Port{
+				{Name: "flight", ContainerPort: 50051},
+				{Name: "other", Protocol: "TCP", ContainerPort: 50051},
+			}
+			cluster.Annotations = map[string]string{RequestedOperationAnnotation: "pause"}
+			minInstances := int32(4)
+			cluster.Spec.Executor.Autoscaling = &ExecutorAutoscaling{MinInstances: &minInstances, MaxInstances: 2}
+			cluster.Spec.Scheduler.StateBackend = &StateBackend{
+				Type: EtcdStateBackend,
+				Sled: &SledBackend{},
+				Etcd: &EtcdBackend{Endpoints: []string{"https://etcd:2379", "etcd:2379"}},
+			}
+
+			err := cluster.ValidateCreate()
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			causes := err.(*apierrors.StatusError).Status().Details.Causes
+			var fields []string
+			for _, cause := range causes {
+				fields = append(fields, cause.Field)
+			}
+			Expect(fields).To(ConsistOf(
+				"spec.ballistaVersion",
+				"spec.scheduler.containers",
+				"spec.scheduler.ports[1].name",
+				"spec.scheduler.ports[1].containerPort",
+				"spec.scheduler.ports[1].protocol",
+				"spec.scheduler.podName",
+				"spec.scheduler.stateBackend.sled",
+				"spec.scheduler.stateBackend.etcd.endpoints[1]",
+				"spec.executor.containers",
+				"spec.executor.ports[1].containerPort",
+				"spec.executor.autoscaling.maxInstances",
+				"metadata.annotations[ballista.minzhou.info/requested-operation]",
+			))
+		})
+
+		It("rejects executor pools with the same name or no container", func() {
+			cluster := newBallistaCluster()
+			cluster.Spec.ExecutorPools = []ExecutorPool{{Name: "spot"}, {Name: "spot"}}
+			cluster.Default()
+			cluster.Spec.ExecutorPools = append(cluster.Spec.ExecutorPools, ExecutorPool{Name: "highmem"})
+
+			err := cluster.ValidateCreate()
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			causes := err.(*apierrors.StatusError).Status().Details.Causes
+			var fields []string
+			for _, cause := range causes {
+				fields = append(fields, cause.Field)
+			}
+			Expect(fields).To(ConsistOf("spec.executorPools[1].name", "spec.executorPools[2].containers"))
+		})
+
+		It("requires the fallback of an executor pool to name another pool", func() {
+			cluster := newBallistaCluster()
+			cluster.Spec.ExecutorPools = []ExecutorPool{
+				{Name: "spot", Fallback: &ExecutorPoolFallback{PoolName: "on-demand", Preemptions: 3}},
+				{Name: "highmem", Fallback: &ExecutorPoolFallback{PoolName: "highmem", Preemptions: 3}},
+			}
+			cluster.Default()
+			Expect(*cluster.Spec.ExecutorPools[0].Fallback.WindowSeconds).To(Equal(DefaultPreemptionWindowSeconds))
+
+			err := cluster.ValidateCreate()
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			causes := err.(*apierrors.StatusError).Status().Details.Causes
+			var fields []string
+			for _, cause := range causes {
+				fields = append(fields, cause.Field)
+			}
+			Expect(fields).To(ConsistOf("spec.executorPools[0].fallback.poolName", "spec.executorPools[1].fallback.poolName"))
+
+			cluster.Spec.ExecutorPools[1] = ExecutorPool{Name: "on-demand"}
+			cluster.Default()
+			Expect(cluster.ValidateCreate()).To(Succeed())
+		})
+
+		DescribeTable("rejects fallbacks to a pool falling back itself",
+			func(pools []ExecutorPool, expected []string) {
+				cluster := newBallistaCluster()
+				cluster.Spec.ExecutorPools = pools
+				cluster.Default()
+
+				err := cluster.ValidateCreate()
+				Expect(apierrors.IsInvalid(err)).To(BeTrue())
+				causes := err.(*apierrors.StatusError).Status().Details.Causes
+				var fields []string
+				for _, cause := range causes {
+					fields = append(fields, cause.Field)
+				}
+				Expect(fields).To(ConsistOf(expected))
+
+				updated := cluster.DeepCopy()
+				Expect(apierrors.IsInvalid(updated.ValidateUpdate(cluster))).To(BeTrue())
+			},
+			Entry("chain", []ExecutorPool{
+				{Name: "spot", Fallback: &ExecutorPoolFallback{PoolName: "preemptible", Preemptions: 3}},
+				{Name: "preemptible", Fallback: &ExecutorPoolFallback{PoolName: "on-demand", Preemptions: 3}},
+				{Name: "on-demand"},
+			}, []string{"spec.executorPools[0].fallback.poolName"}),
+			Entry("cycle", []ExecutorPool{
+				{Name: "spot", Fallback: &ExecutorPoolFallback{PoolName: "preemptible", Preemptions: 3}},
+				{Name: "preemptible", Fallback: &ExecutorPoolFallback{PoolName: "spot", Preemptions: 3}},
+			}, []string{"spec.executorPools[0].fallback.poolName", "spec.executorPools[1].fallback.poolName"}),
+		)
+
+		It("requires the etcd state backend for scheduler replicas", func() {
+			cluster := newBallistaCluster()
+			replicas := int32(3)
+			cluster.Spec.Scheduler.Replicas = &replicas
+			cluster.Default()
+			Expect(cluster.ValidateCreate()).NotTo(Succeed())
+
+			cluster.Spec.Scheduler.StateBackend = &StateBackend{
+				Type: EtcdStateBackend,
+				Etcd: &EtcdBackend{Endpoints: []string{"https://etcd:2379"}},
+			}
+			Expect(cluster.ValidateCreate()).To(Succeed())
+		})
+
+		It("requires etcd endpoints for the etcd state backend", func() {
+			cluster := newBallistaCluster()
+			cluster.Spec.Scheduler.StateBackend = &StateBackend{Type: EtcdStateBackend}
+			cluster.Default()
+			Expect(cluster.ValidateCreate()).NotTo(Succeed())
+
+			cluster.Spec.Scheduler.StateBackend.Etcd = &EtcdBackend{Endpoints: []string{"https://etcd:2379"}}
+			Expect(cluster.ValidateCreate()).To(Succeed())
+		})
+	})
+
+	Context("ValidateUpdate", func() {
+		It("rejects a change of the Sled state backend", func() {
+			old := newBallistaCluster()
+			old.Spec.Scheduler.StateBackend = &StateBackend{Type: SledStateBackend}
+			old.Default()
+			cluster := old.DeepCopy()
+			storageClassName := "fast"
+			cluster.Spec.Scheduler.StateBackend.Sled.StorageClassName = &storageClassName
+			Expect(cluster.ValidateUpdate(old)).NotTo(Succeed())
+		})
+
+		It("accepts a version change", func() {
+			old := newBallistaCluster()
+			old.Default()
+			cluster := old.DeepCopy()
+			cluster.Spec.BallistaVersion = "0.6.0"
+			Expect(cluster.ValidateUpdate(old)).To(Succeed())
+		})
+	})
+
+	DescribeTable("defaultImage",
+		func(image string, expected string) {
+			cluster := newBallistaCluster()
+			if image != "" {
+				cluster.Spec.Image = &image
+			}
+			Expect(cluster.defaultImage()).To(Equal(expected))
+		},
+		Entry("default image", "", "ballista:0.5.0"),
+		Entry("untagged image", "repo/ballista", "repo/ballista:0.5.0"),
+		Entry("untagged image on a registry port", "registry:5000/ballista", "registry:5000/ballista:0.5.0"),
+		Entry("tagged image", "repo/ballista:0.6.0-SNAPSHOT", "repo/ballista:0.6.0-SNAPSHOT"),
+		Entry("image digest", "repo/ballista@sha256:abc", "repo/ballista@sha256:abc"),
+	)
+})
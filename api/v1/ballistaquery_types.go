@@ -0,0 +1,160 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BallistaQuerySpec defines the desired state of BallistaQuery
+type BallistaQuerySpec struct {
+
+	// ClusterName is the name of the BallistaCluster, in the namespace of the query, that runs the query.
+	// +kubebuilder:validation:MinLength=1
+	ClusterName string `json:"clusterName"`
+
+	// SQL is the SQL text of the query. Exactly one of SQL and SQLFrom must be set.
+	// +optional
+	SQL string `json:"sql,omitempty"`
+
+	// SQLFrom reads the SQL text of the query from a key of a ConfigMap in the namespace of the query.
+	// +optional
+	SQLFrom *apiv1.ConfigMapKeySelector `json:"sqlFrom,omitempty"`
+
+	// Tables are registered with the session of the query before it runs.
+	// +optional
+	Tables []QueryTable `json:"tables,omitempty"`
+
+	// Output is where the results of the query are written. The results are kept by the scheduler otherwise.
+	// +optional
+	Output *QueryOutput `json:"output,omitempty"`
+}
+
+// TableFormat is the file format of a table.
+// +kubebuilder:validation:Enum=CSV;Parquet;Avro;JSON
+type TableFormat string
+
+// Different file formats of a table.
+const (
+	CSVTableFormat     TableFormat = "CSV"
+	ParquetTableFormat TableFormat = "Parquet"
+	AvroTableFormat    TableFormat = "Avro"
+	JSONTableFormat    TableFormat = "JSON"
+)
+
+// QueryTable is a table registered with the session of a query.
+type QueryTable struct {
+	// Name is the name the query refers to the table with.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Location is the path or URL of the files of the table.
+	// +kubebuilder:validation:MinLength=1
+	Location string `json:"location"`
+	// Format is the file format of the table.
+	Format TableFormat `json:"format"`
+	// Options are passed to the reader of the table, like "has_header" or "delimiter" for CSV.
+	// +optional
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// QueryOutput is where the results of a query are written.
+type QueryOutput struct {
+	// Location is the path or URL the results are written to.
+	// +kubebuilder:validation:MinLength=1
+	Location string `json:"location"`
+	// Format is the file format of the results.
+	// +kubebuilder:default=Parquet
+	// +optional
+	Format TableFormat `json:"format,omitempty"`
+}
+
+// QueryState tells the current state of a query.
+type QueryState string
+
+// Different states a query may have.
+const (
+	// QueryPendingState is the state of a query waiting for its cluster before it is submitted.
+	QueryPendingState QueryState = "PENDING"
+	// QuerySubmittingState is the state of a query being submitted, recorded before it is submitted so that a
+	// submission whose job ID was not recorded is not repeated.
+	QuerySubmittingState QueryState = "SUBMITTING"
+	QueryQueuedState     QueryState = "QUEUED"
+	QueryRunningState    QueryState = "RUNNING"
+	QuerySucceededState  QueryState = "SUCCEEDED"
+	QueryFailedState     QueryState = "FAILED"
+)
+
+// BallistaQueryStatus defines the observed state of BallistaQuery
+type BallistaQueryStatus struct {
+	// State is the state of the query.
+	// +optional
+	State QueryState `json:"state,omitempty"`
+	// JobID is the ID of the job the scheduler runs the query as.
+	// +optional
+	JobID string `json:"jobID,omitempty"`
+	// Message tells why the query is in its state, like the error it failed with.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// SubmissionTime is the time the query was submitted to the scheduler.
+	// +optional
+	SubmissionTime *metav1.Time `json:"submissionTime,omitempty"`
+	// CompletionTime is the time the query succeeded or failed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// JobStatusFailures is the number of times in a row the status of the job could not be fetched from the
+	// scheduler.
+	// +optional
+	JobStatusFailures int32 `json:"jobStatusFailures,omitempty"`
+}
+
+// IsFinished tells whether the query succeeded or failed.
+func (s *BallistaQueryStatus) IsFinished() bool {
+	return s.State == QuerySucceededState || s.State == QueryFailedState
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:shortName=bq,categories=ballista
+//+kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.spec.clusterName`
+//+kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`
+//+kubebuilder:printcolumn:name="Job",type=string,JSONPath=`.status.jobID`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// BallistaQuery is the Schema for the ballistaqueries API
+// BallistaQuery represents a SQL query submitted to the scheduler of a BallistaCluster. The query is submitted
+// once; a change of its spec afterwards has no effect. Deleting a query that has not finished cancels its job.
+type BallistaQuery struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BallistaQuerySpec   `json:"spec,omitempty"`
+	Status BallistaQueryStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// BallistaQueryList contains a list of BallistaQuery
+type BallistaQueryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BallistaQuery `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BallistaQuery{}, &BallistaQueryList{})
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var ballistaquerylog = logf.Log.WithName("ballistaquery-resource")
+
+func (r *BallistaQuery) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-ballista-minzhou-info-v1-ballistaquery,mutating=false,failurePolicy=fail,sideEffects=None,groups=ballista.minzhou.info,resources=ballistaqueries,verbs=create;update,versions=v1,name=vballistaquery.kb.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Validator = &BallistaQuery{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *BallistaQuery) ValidateCreate() error {
+	ballistaquerylog.Info("validate create", "name", r.Name)
+
+	return r.toAggregate(r.validateBallistaQuery())
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *BallistaQuery) ValidateUpdate(old runtime.Object) error {
+	ballistaquerylog.Info("validate update", "name", r.Name)
+
+	return r.toAggregate(r.validateBallistaQuery())
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *BallistaQuery) ValidateDelete() error {
+	ballistaquerylog.Info("validate delete", "name", r.Name)
+
+	return nil
+}
+
+// toAggregate turns the field errors into the Invalid error the API server reports, or nil if there are none.
+func (r *BallistaQuery) toAggregate(allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("BallistaQuery").GroupKind(), r.Name, allErrs)
+}
+
+// validateBallistaQuery checks the spec of the query.
+func (r *BallistaQuery) validateBallistaQuery() field.ErrorList {
+	return validateBallistaQuerySpec(&r.Spec, field.NewPath("spec"))
+}
+
+// validateBallistaQuerySpec checks the spec of a query at the path, which scheduled queries share.
+func validateBallistaQuerySpec(spec *BallistaQuerySpec, specPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if spec.ClusterName == "" {
+		allErrs = append(allErrs, field.Required(specPath.Child("clusterName"), ""))
+	}
+	switch {
+	case spec.SQL == "" && spec.SQLFrom == nil:
+		allErrs = append(allErrs, field.Required(specPath.Child("sql"), "exactly one of sql and sqlFrom must be set"))
+	case spec.SQL != "" && spec.SQLFrom != nil:
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("sqlFrom"), "exactly one of sql and sqlFrom must be set"))
+	}
+	if ref := spec.SQLFrom; ref != nil {
+		if ref.Name == "" {
+			allErrs = append(allErrs, field.Required(specPath.Child("sqlFrom", "name"), ""))
+		}
+		if ref.Key == "" {
+			allErrs = append(allErrs, field.Required(specPath.Child("sqlFrom", "key"), ""))
+		}
+	}
+	return allErrs
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("BallistaQuery webhook", func() {
+	var query *BallistaQuery
+
+	BeforeEach(func() {
+		query = &BallistaQuery{
+			ObjectMeta: metav1.ObjectMeta{Name: "query", Namespace: "default"},
+			Spec:       BallistaQuerySpec{ClusterName: "cluster", SQL: "SELECT 1"},
+		}
+	})
+
+	invalidFields := func(err error) []string {
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		var fields []string
+		for _, cause := range err.(*apierrors.StatusError).Status().Details.Causes {
+			fields = append(fields, cause.Field)
+		}
+		return fields
+	}
+
+	It("accepts inline SQL", func() {
+		Expect(query.ValidateCreate()).To(Succeed())
+	})
+
+	It("accepts SQL from a ConfigMap", func() {
+		query.Spec.SQL = ""
+		query.Spec.SQLFrom = &apiv1.ConfigMapKeySelector{
+			LocalObjectReference: apiv1.LocalObjectReference{Name: "queries"},
+			Key:                  "fares.sql",
+		}
+		Expect(query.ValidateCreate()).To(Succeed())
+	})
+
+	It("requires a cluster", func() {
+		query.Spec.ClusterName = ""
+		Expect(invalidFields(query.ValidateCreate())).To(ConsistOf("spec.clusterName"))
+	})
+
+	It("requires some SQL", func() {
+		query.Spec.SQL = ""
+		Expect(invalidFields(query.ValidateCreate())).To(ConsistOf("spec.sql"))
+	})
+
+	It("rejects both inline and referenced SQL", func() {
+		query.Spec.SQLFrom = &apiv1.ConfigMapKeySelector{Key: "fares.sql"}
+		Expect(invalidFields(query.ValidateUpdate(query.DeepCopy()))).To(ConsistOf("spec.sqlFrom", "spec.sqlFrom.name"))
+	})
+})
@@ -0,0 +1,128 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BallistaScheduledQuerySpec defines the desired state of BallistaScheduledQuery
+type BallistaScheduledQuerySpec struct {
+
+	// Schedule is the schedule of the runs in Cron format, see https://en.wikipedia.org/wiki/Cron.
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+
+	// StartingDeadlineSeconds is the deadline in seconds for starting a run that missed its scheduled time for
+	// any reason. A run that cannot start before its deadline is skipped.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	StartingDeadlineSeconds *int64 `json:"startingDeadlineSeconds,omitempty"`
+
+	// ConcurrencyPolicy tells how to treat concurrent runs of the query:
+	// - "Allow" (default): allows runs to run concurrently;
+	// - "Forbid": skips the next run if the previous one hasn't finished yet;
+	// - "Replace": cancels the run in progress and replaces it with the next one.
+	// +kubebuilder:default=Allow
+	// +optional
+	ConcurrencyPolicy ConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+
+	// Suspend tells the controller to suspend the subsequent runs. It does not apply to runs already started.
+	// +optional
+	Suspend *bool `json:"suspend,omitempty"`
+
+	// Query is the spec of the BallistaQuery created for every run.
+	Query BallistaQuerySpec `json:"query"`
+
+	// SuccessfulRunsHistoryLimit is the number of successful runs to keep.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=3
+	// +optional
+	SuccessfulRunsHistoryLimit *int32 `json:"successfulRunsHistoryLimit,omitempty"`
+
+	// FailedRunsHistoryLimit is the number of failed runs to keep.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=1
+	// +optional
+	FailedRunsHistoryLimit *int32 `json:"failedRunsHistoryLimit,omitempty"`
+}
+
+// ConcurrencyPolicy tells how to treat concurrent runs of a scheduled query.
+// Only one of the following concurrency policies may be specified.
+// If none of the following policies is specified, the default one
+// is AllowConcurrent.
+// +kubebuilder:validation:Enum=Allow;Forbid;Replace
+type ConcurrencyPolicy string
+
+const (
+	// AllowConcurrent allows runs to run concurrently.
+	AllowConcurrent ConcurrencyPolicy = "Allow"
+
+	// ForbidConcurrent forbids concurrent runs, skipping the next run if the previous one hasn't finished yet.
+	ForbidConcurrent ConcurrencyPolicy = "Forbid"
+
+	// ReplaceConcurrent cancels the run in progress and replaces it with the next one.
+	ReplaceConcurrent ConcurrencyPolicy = "Replace"
+)
+
+// BallistaScheduledQueryStatus defines the observed state of BallistaScheduledQuery
+type BallistaScheduledQueryStatus struct {
+	// Active are the runs that have not finished yet.
+	// +optional
+	Active []apiv1.ObjectReference `json:"active,omitempty"`
+
+	// LastScheduleTime is the last time a run was scheduled.
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// LastSuccessfulTime is the last time a run succeeded.
+	// +optional
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:shortName=bsq,categories=ballista
+//+kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=`.spec.schedule`
+//+kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.spec.query.clusterName`
+//+kubebuilder:printcolumn:name="Suspend",type=boolean,JSONPath=`.spec.suspend`
+//+kubebuilder:printcolumn:name="Last Schedule",type=date,JSONPath=`.status.lastScheduleTime`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// BallistaScheduledQuery is the Schema for the ballistascheduledqueries API
+// BallistaScheduledQuery runs a BallistaQuery on a schedule, the way a CronJob runs a Job.
+type BallistaScheduledQuery struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BallistaScheduledQuerySpec   `json:"spec,omitempty"`
+	Status BallistaScheduledQueryStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// BallistaScheduledQueryList contains a list of BallistaScheduledQuery
+type BallistaScheduledQueryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BallistaScheduledQuery `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BallistaScheduledQuery{}, &BallistaScheduledQueryList{})
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"github.com/robfig/cron/v3"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var ballistascheduledquerylog = logf.Log.WithName("ballistascheduledquery-resource")
+
+func (r *BallistaScheduledQuery) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-ballista-minzhou-info-v1-ballistascheduledquery,mutating=false,failurePolicy=fail,sideEffects=None,groups=ballista.minzhou.info,resources=ballistascheduledqueries,verbs=create;update,versions=v1,name=vballistascheduledquery.kb.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Validator = &BallistaScheduledQuery{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *BallistaScheduledQuery) ValidateCreate() error {
+	ballistascheduledquerylog.Info("validate create", "name", r.Name)
+
+	return r.toAggregate(r.validateBallistaScheduledQuery())
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *BallistaScheduledQuery) ValidateUpdate(old runtime.Object) error {
+	ballistascheduledquerylog.Info("validate update", "name", r.Name)
+
+	return r.toAggregate(r.validateBallistaScheduledQuery())
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *BallistaScheduledQuery) ValidateDelete() error {
+	ballistascheduledquerylog.Info("validate delete", "name", r.Name)
+
+	return nil
+}
+
+// toAggregate turns the field errors into the Invalid error the API server reports, or nil if there are none.
+func (r *BallistaScheduledQuery) toAggregate(allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("BallistaScheduledQuery").GroupKind(), r.Name, allErrs)
+}
+
+// validateBallistaScheduledQuery checks the schedule of the scheduled query, parsed as the controller does,
+// and the spec of the queries it creates.
+func (r *BallistaScheduledQuery) validateBallistaScheduledQuery() field.ErrorList {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if _, err := cron.ParseStandard(r.Spec.Schedule); err != nil {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("schedule"), r.Spec.Schedule, err.Error()))
+	}
+	allErrs = append(allErrs, validateBallistaQuerySpec(&r.Spec.Query, specPath.Child("query"))...)
+	return allErrs
+}
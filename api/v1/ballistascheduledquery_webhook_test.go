@@ -0,0 +1,74 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("BallistaScheduledQuery webhook", func() {
+	var scheduledQuery *BallistaScheduledQuery
+
+	BeforeEach(func() {
+		scheduledQuery = &BallistaScheduledQuery{
+			ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "default"},
+			Spec: BallistaScheduledQuerySpec{
+				Schedule: "0 2 * * *",
+				Query:    BallistaQuerySpec{ClusterName: "cluster", SQL: "SELECT 1"},
+			},
+		}
+	})
+
+	invalidFields := func(err error) []string {
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		var fields []string
+		for _, cause := range err.(*apierrors.StatusError).Status().Details.Causes {
+			fields = append(fields, cause.Field)
+		}
+		return fields
+	}
+
+	It("accepts a query on a schedule", func() {
+		Expect(scheduledQuery.ValidateCreate()).To(Succeed())
+
+		scheduledQuery.Spec.Schedule = "@hourly"
+		Expect(scheduledQuery.ValidateUpdate(scheduledQuery.DeepCopy())).To(Succeed())
+	})
+
+	It("rejects an invalid schedule", func() {
+		scheduledQuery.Spec.Schedule = "0 2 * *"
+		Expect(invalidFields(scheduledQuery.ValidateCreate())).To(ConsistOf("spec.schedule"))
+
+		scheduledQuery.Spec.Schedule = "every night"
+		Expect(invalidFields(scheduledQuery.ValidateUpdate(scheduledQuery.DeepCopy()))).To(ConsistOf("spec.schedule"))
+	})
+
+	It("requires some SQL and a cluster", func() {
+		scheduledQuery.Spec.Query = BallistaQuerySpec{}
+		Expect(invalidFields(scheduledQuery.ValidateCreate())).To(ConsistOf("spec.query.clusterName", "spec.query.sql"))
+	})
+
+	It("rejects both inline and referenced SQL", func() {
+		scheduledQuery.Spec.Query.SQLFrom = &apiv1.ConfigMapKeySelector{Key: "fares.sql"}
+		Expect(invalidFields(scheduledQuery.ValidateUpdate(scheduledQuery.DeepCopy()))).To(
+			ConsistOf("spec.query.sqlFrom", "spec.query.sqlFrom.name"))
+	})
+})
@@ -0,0 +1,160 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	k8sapiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+// autoscalePollInterval is how often the autoscaler polls the scheduler for its job and task counts.
+const autoscalePollInterval = 15 * time.Second
+
+// autoscaleExecutors polls the scheduler for its job and task counts and sizes the executors after them, as
+// Spec.Executor.Autoscaling asks. The decision is recorded in Status.Autoscaling, which executorInstances
+// follows. It returns how long to wait before polling again, zero when the cluster is not autoscaled.
+func (r *BallistaClusterReconciler) autoscaleExecutors(ctx context.Context, cluster *v1.BallistaCluster, now time.Time) time.Duration {
+	log := log.FromContext(ctx)
+
+	autoscaling := cluster.Spec.Executor.Autoscaling
+	if autoscaling == nil {
+		cluster.Status.Autoscaling = nil
+		return 0
+	}
+	status := cluster.Status.Autoscaling
+	if status == nil {
+		// start from the configured instances until the scheduler tells otherwise
+		instances := 1
+		if cluster.Spec.Executor.Instances != nil {
+			instances = int(*cluster.Spec.Executor.Instances)
+		}
+		status = &v1.AutoscalingStatus{DesiredExecutors: int32(clampExecutors(autoscaling, instances))}
+		cluster.Status.Autoscaling = status
+	}
+	if r.SchedulerClient == nil || cluster.Status.SchedulerState != v1.SchedulerRunningState {
+		return autoscalePollInterval
+	}
+
+	endpoint := schedulerEndpoint(cluster)
+	metrics, err := r.SchedulerClient.Metrics(ctx, endpoint)
+	if err != nil {
+		log.Error(err, "unable to read scheduler metrics", "endpoint", endpoint)
+		return autoscalePollInterval
+	}
+	// the scheduler does not tell which executors run tasks, so every registered executor of Spec.Executor is
+	// busy while jobs run
+	busy := 0
+	if metrics.ActiveJobs > 0 {
+		executors, err := r.SchedulerClient.Executors(ctx, endpoint)
+		if err != nil {
+			log.Error(err, "unable to list executors registered with the scheduler", "endpoint", endpoint)
+			return autoscalePollInterval
+		}
+		pods, err := r.listClusterPods(ctx, cluster, executorRole)
+		if err != nil {
+			log.Error(err, "unable to list executor pods")
+			return autoscalePollInterval
+		}
+		busy = countAutoscaledExecutors(pods, executors)
+	}
+	status.PendingTasks = int32(metrics.PendingTasks)
+	status.ActiveJobs = int32(metrics.ActiveJobs)
+
+	desired := desiredExecutors(autoscaling, busy, metrics.PendingTasks)
+	if scaleAllowed(autoscaling, status, desired, now) {
+		log.Info("autoscaling executors", "from", status.DesiredExecutors, "to", desired,
+			"pendingTasks", metrics.PendingTasks, "busyExecutors", busy)
+		r.Recorder.Eventf(cluster, k8sapiv1.EventTypeNormal, eventExecutorsAutoscaled,
+			"Autoscaled executors from %d to %d for %d pending tasks", status.DesiredExecutors, desired, metrics.PendingTasks)
+		status.DesiredExecutors = int32(desired)
+		lastScaleTime := metav1.NewTime(now)
+		status.LastScaleTime = &lastScaleTime
+	}
+	return autoscalePollInterval
+}
+
+// countAutoscaledExecutors returns how many of the executors registered with the scheduler run in an executor
+// pod of Spec.Executor rather than of an executor pool, which the autoscaler does not scale.
+func countAutoscaledExecutors(pods []k8sapiv1.Pod, executors []SchedulerExecutor) int {
+	hosts := make(map[string]bool, len(pods))
+	for _, pod := range pods {
+		if _, ok := pod.Labels[podExecutorPoolKey]; !ok && pod.Status.PodIP != "" {
+			hosts[pod.Status.PodIP] = true
+		}
+	}
+	count := 0
+	for _, executor := range executors {
+		if hosts[executor.Host] {
+			count++
+		}
+	}
+	return count
+}
+
+// desiredExecutors returns the number of executors that keeps the busy ones and adds one for every
+// TargetPendingTasksPerExecutor pending tasks, within the autoscaling bounds.
+func desiredExecutors(autoscaling *v1.ExecutorAutoscaling, busy int, pendingTasks int) int {
+	target := int(v1.DefaultTargetPendingTasksPerExecutor)
+	if autoscaling.TargetPendingTasksPerExecutor != nil {
+		target = int(*autoscaling.TargetPendingTasksPerExecutor)
+	}
+	return clampExecutors(autoscaling, busy+(pendingTasks+target-1)/target)
+}
+
+// clampExecutors bounds the number of executors by MinInstances and MaxInstances.
+func clampExecutors(autoscaling *v1.ExecutorAutoscaling, executors int) int {
+	minInstances := int(v1.DefaultAutoscalingMinInstances)
+	if autoscaling.MinInstances != nil {
+		minInstances = int(*autoscaling.MinInstances)
+	}
+	if executors < minInstances {
+		executors = minInstances
+	}
+	if executors > int(autoscaling.MaxInstances) {
+		executors = int(autoscaling.MaxInstances)
+	}
+	return executors
+}
+
+// scaleAllowed tells whether the autoscaler may change the number of executors to desired, given the
+// cooldown since it last did.
+func scaleAllowed(autoscaling *v1.ExecutorAutoscaling, status *v1.AutoscalingStatus, desired int, now time.Time) bool {
+	current := int(status.DesiredExecutors)
+	if desired == current {
+		return false
+	}
+	if status.LastScaleTime == nil {
+		return true
+	}
+	cooldown := v1.DefaultScaleUpCooldownSeconds
+	if desired > current && autoscaling.ScaleUpCooldownSeconds != nil {
+		cooldown = *autoscaling.ScaleUpCooldownSeconds
+	}
+	if desired < current {
+		cooldown = v1.DefaultScaleDownCooldownSeconds
+		if autoscaling.ScaleDownCooldownSeconds != nil {
+			cooldown = *autoscaling.ScaleDownCooldownSeconds
+		}
+	}
+	return !status.LastScaleTime.Add(time.Duration(cooldown) * time.Second).After(now)
+}
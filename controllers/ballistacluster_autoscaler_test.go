@@ -0,0 +1,178 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	k8sapiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+var _ = Describe("BallistaCluster executor autoscaler", func() {
+	var autoscaling *v1.ExecutorAutoscaling
+
+	BeforeEach(func() {
+		autoscaling = &v1.ExecutorAutoscaling{
+			MinInstances:                  int32Ptr(1),
+			MaxInstances:                  10,
+			TargetPendingTasksPerExecutor: int32Ptr(4),
+			ScaleUpCooldownSeconds:        int32Ptr(30),
+			ScaleDownCooldownSeconds:      int32Ptr(300),
+		}
+	})
+
+	DescribeTable("desiredExecutors",
+		func(busy, pending, expected int) {
+			Expect(desiredExecutors(autoscaling, busy, pending)).To(Equal(expected))
+		},
+		Entry("idle", 0, 0, 1),
+		Entry("keeps busy executors", 3, 0, 3),
+		Entry("adds executors for pending tasks", 2, 5, 4),
+		Entry("stops at the maximum", 8, 40, 10),
+	)
+
+	DescribeTable("scaleAllowed",
+		func(current, desired int, sinceLastScale time.Duration, expected bool) {
+			now := time.Now()
+			lastScaleTime := metav1.NewTime(now.Add(-sinceLastScale))
+			status := &v1.AutoscalingStatus{DesiredExecutors: int32(current), LastScaleTime: &lastScaleTime}
+			Expect(scaleAllowed(autoscaling, status, desired, now)).To(Equal(expected))
+		},
+		Entry("unchanged", 3, 3, time.Hour, false),
+		Entry("up after the cooldown", 3, 5, time.Minute, true),
+		Entry("up within the cooldown", 3, 5, 10*time.Second, false),
+		Entry("down within the cooldown", 5, 3, time.Minute, false),
+		Entry("down after the cooldown", 5, 3, 10*time.Minute, true),
+	)
+
+	Context("autoscaleExecutors", func() {
+		var cluster *v1.BallistaCluster
+		var scheduler *fakeSchedulerClient
+
+		BeforeEach(func() {
+			cluster = &v1.BallistaCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"}}
+			cluster.Spec.Executor.Instances = int32Ptr(2)
+			cluster.Spec.Executor.Autoscaling = autoscaling
+			cluster.Status.SchedulerState = v1.SchedulerRunningState
+			scheduler = &fakeSchedulerClient{}
+		})
+
+		executor := func(name, ip, pool string) *k8sapiv1.Pod {
+			labels := map[string]string{podBallistaRoleKey: executorRole}
+			if pool != "" {
+				labels[podExecutorPoolKey] = pool
+			}
+			return &k8sapiv1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+				Status:     k8sapiv1.PodStatus{Phase: k8sapiv1.PodRunning, PodIP: ip},
+			}
+		}
+
+		reconciler := func(executors ...client.Object) *BallistaClusterReconciler {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			return &BallistaClusterReconciler{
+				Client:          fake.NewClientBuilder().WithScheme(scheme).WithObjects(executors...).Build(),
+				SchedulerClient: scheduler,
+				Recorder:        record.NewFakeRecorder(10),
+				Clock:           clock.NewFakeClock(time.Now()),
+			}
+		}
+
+		It("follows the task queue of the scheduler", func() {
+			scheduler.metrics = SchedulerMetrics{PendingTasks: 8, ActiveJobs: 1}
+			scheduler.executors = []SchedulerExecutor{{ID: "a", Host: "10.0.0.1"}}
+			r := reconciler(executor("cluster-executor-0", "10.0.0.1", ""))
+
+			Expect(r.autoscaleExecutors(context.Background(), cluster, r.Clock.Now())).To(Equal(autoscalePollInterval))
+			Expect(cluster.Status.Autoscaling.DesiredExecutors).To(Equal(int32(3)))
+			Expect(cluster.Status.Autoscaling.PendingTasks).To(Equal(int32(8)))
+			Expect(cluster.Status.Autoscaling.ActiveJobs).To(Equal(int32(1)))
+			Expect(cluster.Status.Autoscaling.LastScaleTime).NotTo(BeNil())
+			Expect(executorInstances(cluster)).To(Equal(3))
+			Expect(r.Recorder.(*record.FakeRecorder).Events).To(Receive(ContainSubstring(eventExecutorsAutoscaled)))
+		})
+
+		It("counts the busy executors of Spec.Executor alone", func() {
+			cluster.Spec.ExecutorPools = []v1.ExecutorPool{{Name: "spot", Instances: int32Ptr(4)}}
+			scheduler.metrics = SchedulerMetrics{PendingTasks: 8, ActiveJobs: 1}
+			scheduler.executors = []SchedulerExecutor{
+				{ID: "a", Host: "10.0.0.1"}, {ID: "b", Host: "10.0.0.2"}, {ID: "c", Host: "10.0.0.3"}}
+			r := reconciler(executor("cluster-executor-0", "10.0.0.1", ""),
+				executor("cluster-spot-0", "10.0.0.2", "spot"), executor("cluster-spot-1", "10.0.0.3", "spot"))
+
+			r.autoscaleExecutors(context.Background(), cluster, r.Clock.Now())
+			Expect(cluster.Status.Autoscaling.DesiredExecutors).To(Equal(int32(3)))
+		})
+
+		It("scales again once the cooldown passed on the clock", func() {
+			fakeClock := clock.NewFakeClock(time.Now())
+			scheduler.metrics = SchedulerMetrics{PendingTasks: 12}
+			r := &BallistaClusterReconciler{SchedulerClient: scheduler, Recorder: record.NewFakeRecorder(10), Clock: fakeClock}
+			r.autoscaleExecutors(context.Background(), cluster, fakeClock.Now())
+			Expect(cluster.Status.Autoscaling.DesiredExecutors).To(Equal(int32(3)))
+			Expect(cluster.Status.Autoscaling.LastScaleTime.Time).To(Equal(fakeClock.Now()))
+
+			scheduler.metrics = SchedulerMetrics{PendingTasks: 24}
+			fakeClock.Step(29 * time.Second)
+			r.autoscaleExecutors(context.Background(), cluster, fakeClock.Now())
+			Expect(cluster.Status.Autoscaling.DesiredExecutors).To(Equal(int32(3)))
+
+			fakeClock.Step(time.Second)
+			r.autoscaleExecutors(context.Background(), cluster, fakeClock.Now())
+			Expect(cluster.Status.Autoscaling.DesiredExecutors).To(Equal(int32(6)))
+			Expect(cluster.Status.Autoscaling.LastScaleTime.Time).To(Equal(fakeClock.Now()))
+		})
+
+		It("keeps the executors when the scheduler cannot tell", func() {
+			scheduler.err = errors.New("unavailable")
+			r := &BallistaClusterReconciler{SchedulerClient: scheduler, Recorder: record.NewFakeRecorder(10), Clock: clock.NewFakeClock(time.Now())}
+
+			r.autoscaleExecutors(context.Background(), cluster, r.Clock.Now())
+			Expect(cluster.Status.Autoscaling.DesiredExecutors).To(Equal(int32(2)))
+			Expect(cluster.Status.Autoscaling.LastScaleTime).To(BeNil())
+		})
+
+		It("forgets its decisions once autoscaling is off", func() {
+			cluster.Status.Autoscaling = &v1.AutoscalingStatus{DesiredExecutors: 7}
+			cluster.Spec.Executor.Autoscaling = nil
+			r := &BallistaClusterReconciler{SchedulerClient: scheduler, Recorder: record.NewFakeRecorder(10), Clock: clock.NewFakeClock(time.Now())}
+
+			Expect(r.autoscaleExecutors(context.Background(), cluster, r.Clock.Now())).To(BeZero())
+			Expect(cluster.Status.Autoscaling).To(BeNil())
+			Expect(executorInstances(cluster)).To(Equal(2))
+		})
+	})
+})
@@ -0,0 +1,177 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+// setClusterConditions derives the conditions of the cluster from its status, and the error reconciling it
+// ran into, if any. The Suspended condition is left to reconcileIdleness.
+func setClusterConditions(cluster *v1.BallistaCluster, reconcileErr error) {
+	status := &cluster.Status
+	status.ObservedGeneration = cluster.Generation
+
+	schedulerReady := schedulerReadyCondition(cluster)
+	executorsReady := executorsReadyCondition(cluster)
+	for _, condition := range []metav1.Condition{
+		schedulerReady,
+		executorsReady,
+		availableCondition(cluster, schedulerReady, executorsReady),
+		progressingCondition(cluster),
+		degradedCondition(cluster, reconcileErr),
+	} {
+		condition.ObservedGeneration = cluster.Generation
+		meta.SetStatusCondition(&status.Conditions, condition)
+	}
+}
+
+// schedulerReadyCondition tells whether the scheduler runs, that is whether one of its replicas does.
+func schedulerReadyCondition(cluster *v1.BallistaCluster) metav1.Condition {
+	condition := metav1.Condition{Type: v1.SchedulerReadyCondition, Status: metav1.ConditionFalse}
+	switch cluster.Status.SchedulerState {
+	case v1.SchedulerRunningState:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = v1.SchedulerRunningReason
+		condition.Message = "scheduler is running"
+		if replicas := schedulerReplicas(cluster); replicas > 1 {
+			condition.Message = fmt.Sprintf("scheduler is running, %d/%d replicas ready", cluster.Status.ReadySchedulers, replicas)
+		}
+	case v1.SchedulerPendingState:
+		condition.Reason = v1.SchedulerPendingReason
+		condition.Message = "scheduler is starting"
+	case v1.SchedulerFailedState, v1.SchedulerCompletedState:
+		condition.Reason = v1.SchedulerFailedReason
+		condition.Message = cluster.Status.ClusterState.ErrorMessage
+	default:
+		condition.Reason = v1.SchedulerUnknownReason
+		condition.Message = "scheduler cannot be observed"
+	}
+	return condition
+}
+
+// executorsReadyCondition tells whether the desired number of executors are ready.
+func executorsReadyCondition(cluster *v1.BallistaCluster) metav1.Condition {
+	total := totalExecutors(cluster)
+	condition := metav1.Condition{
+		Type:    v1.ExecutorsReadyCondition,
+		Status:  metav1.ConditionFalse,
+		Message: fmt.Sprintf("%d/%d executors ready", total.ReadyExecutors, executorInstances(cluster)),
+	}
+	switch {
+	case int(total.ReadyExecutors) >= executorInstances(cluster):
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = v1.ExecutorsRunningReason
+	case total.FailedExecutors > 0:
+		condition.Reason = v1.ExecutorsFailedReason
+		condition.Message = fmt.Sprintf("%s, %d failed", condition.Message, total.FailedExecutors)
+	default:
+		condition.Reason = v1.ExecutorsPendingReason
+	}
+	return condition
+}
+
+// availableCondition tells whether the cluster serves with its scheduler and all its executors, which it
+// still does while waiting to restart or terminate.
+func availableCondition(cluster *v1.BallistaCluster, schedulerReady, executorsReady metav1.Condition) metav1.Condition {
+	state := cluster.Status.ClusterState.State
+	switch state {
+	case v1.RunningState, v1.RestartWhenReadyState, v1.TerminateWhenReady:
+		if schedulerReady.Status == metav1.ConditionTrue && executorsReady.Status == metav1.ConditionTrue {
+			return metav1.Condition{
+				Type:    v1.AvailableCondition,
+				Status:  metav1.ConditionTrue,
+				Reason:  v1.ClusterRunningReason,
+				Message: fmt.Sprintf("cluster is %s", state),
+			}
+		}
+	}
+	return metav1.Condition{
+		Type:    v1.AvailableCondition,
+		Status:  metav1.ConditionFalse,
+		Reason:  v1.ClusterNotRunningReason,
+		Message: fmt.Sprintf("cluster is %s", clusterStateName(state)),
+	}
+}
+
+// progressingCondition tells whether the cluster is on its way to another state.
+func progressingCondition(cluster *v1.BallistaCluster) metav1.Condition {
+	status := &cluster.Status
+	condition := metav1.Condition{Type: v1.ProgressingCondition, Status: metav1.ConditionTrue}
+	switch state := status.ClusterState.State; {
+	case state == v1.Terminating || state == v1.TerminateWhenReady:
+		condition.Reason = v1.TerminatingReason
+		condition.Message = fmt.Sprintf("cluster is %s", state)
+	case state == v1.Restarting || state == v1.RestartWhenReadyState:
+		condition.Reason = v1.RestartingReason
+		condition.Message = fmt.Sprintf("cluster is %s", state)
+	case status.Upgrade != nil &&
+		(status.Upgrade.Phase == v1.UpgradingSchedulerPhase || status.Upgrade.Phase == v1.UpgradingExecutorsPhase):
+		condition.Reason = v1.UpgradingReason
+		condition.Message = fmt.Sprintf("upgrading to version %s, %s", status.Upgrade.TargetVersion, status.Upgrade.Phase)
+	case state == v1.NewState || state == v1.Pending && status.SchedulerState != v1.SchedulerRunningState:
+		condition.Reason = v1.StartingReason
+		condition.Message = "waiting for the scheduler"
+	case state != v1.Terminated && int(totalExecutors(cluster).Executors) != executorInstances(cluster):
+		condition.Reason = v1.ScalingReason
+		condition.Message = fmt.Sprintf("scaling from %d to %d executors", totalExecutors(cluster).Executors,
+			executorInstances(cluster))
+	default:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = v1.StableReason
+		condition.Message = fmt.Sprintf("cluster is %s", clusterStateName(state))
+	}
+	return condition
+}
+
+// degradedCondition tells whether the cluster fails to reach its desired state.
+func degradedCondition(cluster *v1.BallistaCluster, reconcileErr error) metav1.Condition {
+	status := &cluster.Status
+	condition := metav1.Condition{Type: v1.DegradedCondition, Status: metav1.ConditionTrue}
+	switch {
+	case reconcileErr != nil:
+		condition.Reason = v1.ReconcileErrorReason
+		condition.Message = reconcileErr.Error()
+	case status.Upgrade != nil && status.Upgrade.Phase == v1.UpgradeFailedPhase:
+		condition.Reason = v1.UpgradeFailedReason
+		condition.Message = status.Upgrade.Message
+	case status.SchedulerState == v1.SchedulerFailedState || status.SchedulerState == v1.SchedulerCompletedState:
+		condition.Reason = v1.SchedulerFailedReason
+		condition.Message = status.ClusterState.ErrorMessage
+	case totalExecutors(cluster).FailedExecutors > 0:
+		condition.Reason = v1.ExecutorsFailedReason
+		condition.Message = fmt.Sprintf("%d executors failed", totalExecutors(cluster).FailedExecutors)
+	default:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = v1.AsExpectedReason
+		condition.Message = "cluster is as expected"
+	}
+	return condition
+}
+
+// clusterStateName returns the state for messages, naming the state of a cluster yet to start.
+func clusterStateName(state v1.ClusterStateType) string {
+	if state == v1.NewState {
+		return "NEW"
+	}
+	return string(state)
+}
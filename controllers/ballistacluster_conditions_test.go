@@ -0,0 +1,113 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+var _ = Describe("BallistaCluster conditions", func() {
+	var cluster *v1.BallistaCluster
+
+	BeforeEach(func() {
+		cluster = &v1.BallistaCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Generation: 3}}
+		cluster.Spec.Executor.Instances = int32Ptr(2)
+		cluster.Status.ClusterState.State = v1.RunningState
+		cluster.Status.SchedulerState = v1.SchedulerRunningState
+		cluster.Status.ReadyExecutors = 2
+		cluster.Status.Executors = 2
+	})
+
+	condition := func(conditionType string) *metav1.Condition {
+		return meta.FindStatusCondition(cluster.Status.Conditions, conditionType)
+	}
+
+	It("reports a running cluster as available", func() {
+		setClusterConditions(cluster, nil)
+
+		Expect(cluster.Status.ObservedGeneration).To(Equal(int64(3)))
+		for _, conditionType := range []string{v1.SchedulerReadyCondition, v1.ExecutorsReadyCondition, v1.AvailableCondition} {
+			Expect(meta.IsStatusConditionTrue(cluster.Status.Conditions, conditionType)).To(BeTrue(), conditionType)
+		}
+		Expect(condition(v1.ProgressingCondition).Reason).To(Equal(v1.StableReason))
+		Expect(condition(v1.DegradedCondition).Reason).To(Equal(v1.AsExpectedReason))
+		Expect(condition(v1.AvailableCondition).ObservedGeneration).To(Equal(int64(3)))
+	})
+
+	It("reports a starting cluster as progressing", func() {
+		cluster.Status.ClusterState.State = v1.Pending
+		cluster.Status.SchedulerState = v1.SchedulerPendingState
+		cluster.Status.ReadyExecutors = 0
+		cluster.Status.Executors = 0
+		setClusterConditions(cluster, nil)
+
+		Expect(meta.IsStatusConditionFalse(cluster.Status.Conditions, v1.AvailableCondition)).To(BeTrue())
+		Expect(condition(v1.ProgressingCondition).Reason).To(Equal(v1.StartingReason))
+		Expect(condition(v1.ExecutorsReadyCondition).Reason).To(Equal(v1.ExecutorsPendingReason))
+	})
+
+	It("reports scaling", func() {
+		cluster.Spec.Executor.Instances = int32Ptr(4)
+		setClusterConditions(cluster, nil)
+
+		Expect(condition(v1.ProgressingCondition).Reason).To(Equal(v1.ScalingReason))
+		Expect(condition(v1.ExecutorsReadyCondition).Message).To(Equal("2/4 executors ready"))
+	})
+
+	It("reports an upgrade", func() {
+		cluster.Status.Upgrade = &v1.UpgradeStatus{Phase: v1.UpgradingExecutorsPhase, TargetVersion: "0.6.0"}
+		setClusterConditions(cluster, nil)
+		Expect(condition(v1.ProgressingCondition).Reason).To(Equal(v1.UpgradingReason))
+
+		cluster.Status.Upgrade.Phase = v1.UpgradeFailedPhase
+		cluster.Status.Upgrade.Message = "halted"
+		setClusterConditions(cluster, nil)
+		Expect(condition(v1.ProgressingCondition).Reason).To(Equal(v1.StableReason))
+		Expect(condition(v1.DegradedCondition).Reason).To(Equal(v1.UpgradeFailedReason))
+		Expect(condition(v1.DegradedCondition).Message).To(Equal("halted"))
+	})
+
+	It("reports failures as degraded", func() {
+		cluster.Status.SchedulerState = v1.SchedulerFailedState
+		cluster.Status.ClusterState.ErrorMessage = "scheduler pod cluster-scheduler is FAILED: OOMKilled"
+		setClusterConditions(cluster, nil)
+		Expect(condition(v1.SchedulerReadyCondition).Reason).To(Equal(v1.SchedulerFailedReason))
+		Expect(condition(v1.DegradedCondition).Reason).To(Equal(v1.SchedulerFailedReason))
+		Expect(meta.IsStatusConditionFalse(cluster.Status.Conditions, v1.AvailableCondition)).To(BeTrue())
+
+		setClusterConditions(cluster, errors.New("boom"))
+		Expect(condition(v1.DegradedCondition).Reason).To(Equal(v1.ReconcileErrorReason))
+	})
+
+	It("leaves the suspension alone", func() {
+		meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
+			Type: v1.SuspendedCondition, Status: metav1.ConditionTrue, Reason: v1.IdleTimeoutReason,
+		})
+		cluster.Status.ReadyExecutors = 0
+		cluster.Status.Executors = 0
+		setClusterConditions(cluster, nil)
+		Expect(isClusterSuspended(cluster)).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(cluster.Status.Conditions, v1.AvailableCondition)).To(BeTrue())
+	})
+})
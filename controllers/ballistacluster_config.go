@@ -0,0 +1,196 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	k8sapiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+const (
+	// configChecksumAnnotation records on a pod the checksum of the configuration file it was created with, so
+	// that a change of the configuration rolls the pods.
+	configChecksumAnnotation = "ballista.minzhou.info/config-checksum"
+	// schedulerConfigKey is the key of the scheduler configuration file in the cluster ConfigMap.
+	schedulerConfigKey = "scheduler.toml"
+	// executorConfigKey is the key of the executor configuration file in the cluster ConfigMap.
+	executorConfigKey = "executor.toml"
+	// configVolumeName is the name of the volume the configuration file is mounted from.
+	configVolumeName = "ballista-config"
+	// configMountPath is the directory the configuration file is mounted in.
+	configMountPath = "/etc/ballista"
+	// configFileName is the name of the mounted configuration file.
+	configFileName = "ballista.toml"
+)
+
+// bareConfigKey matches the setting names that need no quoting in a configuration file.
+var bareConfigKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// reconcileConfigMap creates or updates the ConfigMap holding the configuration files of the scheduler and
+// the executors of the cluster.
+func (r *BallistaClusterReconciler) reconcileConfigMap(ctx context.Context, cluster *v1.BallistaCluster) error {
+	log := log.FromContext(ctx)
+
+	configMap := &k8sapiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configMapName(cluster),
+			Namespace: cluster.Namespace,
+		},
+	}
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, configMap, func() error {
+		if configMap.Labels == nil {
+			configMap.Labels = make(map[string]string)
+		}
+		configMap.Labels[podClusterNameKey] = cluster.Name
+		if cluster.Status.ClusterID != "" {
+			configMap.Labels[podClusterIDKey] = cluster.Status.ClusterID
+		}
+		configMap.Data = map[string]string{
+			schedulerConfigKey: schedulerConfig(cluster),
+			executorConfigKey:  executorConfig(cluster),
+		}
+		return ctrl.SetControllerReference(cluster, configMap, r.Scheme)
+	})
+	if err != nil {
+		log.Error(err, "unable to reconcile config map for Ballista Cluster", "configMap", configMap.Name)
+		return err
+	}
+	if result != controllerutil.OperationResultNone {
+		log.Info("reconciled config map", "configMap", configMap.Name, "result", result)
+	}
+	return nil
+}
+
+// schedulerConfig renders the configuration file of the scheduler.
+func schedulerConfig(cluster *v1.BallistaCluster) string {
+	settings := configProperties(cluster.Spec.Scheduler.Properties)
+	if config := cluster.Spec.Scheduler.Config; config != nil {
+		if config.LogLevel != nil {
+			settings["log_level_setting"] = strconv.Quote(string(*config.LogLevel))
+		}
+		if config.ShufflePartitions != nil {
+			settings["ballista.shuffle.partitions"] = fmt.Sprint(*config.ShufflePartitions)
+		}
+		if config.BatchSize != nil {
+			settings["ballista.batch.size"] = fmt.Sprint(*config.BatchSize)
+		}
+	}
+	for name, value := range stateBackendSettings(cluster) {
+		settings[name] = value
+	}
+	return renderConfig(settings)
+}
+
+// executorConfig renders the configuration file of the executors.
+func executorConfig(cluster *v1.BallistaCluster) string {
+	settings := configProperties(cluster.Spec.Executor.Properties)
+	if config := cluster.Spec.Executor.Config; config != nil {
+		if config.LogLevel != nil {
+			settings["log_level_setting"] = strconv.Quote(string(*config.LogLevel))
+		}
+		if config.ConcurrentTasks != nil {
+			settings["concurrent_tasks"] = fmt.Sprint(*config.ConcurrentTasks)
+		}
+		if config.WorkDir != nil {
+			settings["work_dir"] = strconv.Quote(*config.WorkDir)
+		}
+	}
+	return renderConfig(settings)
+}
+
+// configProperties returns the free-form properties as settings: numbers and booleans are kept as they are,
+// anything else becomes a string.
+func configProperties(properties map[string]string) map[string]string {
+	settings := make(map[string]string, len(properties))
+	for name, value := range properties {
+		if _, err := strconv.ParseFloat(value, 64); err == nil || value == "true" || value == "false" {
+			settings[name] = value
+		} else {
+			settings[name] = strconv.Quote(value)
+		}
+	}
+	return settings
+}
+
+// renderConfig renders the settings as a TOML configuration file, sorted by name so that the same settings
+// always render the same.
+func renderConfig(settings map[string]string) string {
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var config strings.Builder
+	for _, name := range names {
+		key := name
+		if !bareConfigKey.MatchString(key) {
+			key = strconv.Quote(key)
+		}
+		fmt.Fprintf(&config, "%s = %s\n", key, settings[name])
+	}
+	return config.String()
+}
+
+// mountConfig mounts the configuration file under the given key of the cluster ConfigMap into the first
+// container of the pod template, and records the checksum of the file on the template.
+func mountConfig(cluster *v1.BallistaCluster, template *k8sapiv1.PodTemplateSpec, key string, config string) {
+	if template.Annotations == nil {
+		template.Annotations = make(map[string]string)
+	}
+	template.Annotations[configChecksumAnnotation] = rand.SafeEncodeString(fmt.Sprint(hashString(config)))
+
+	spec := &template.Spec
+	spec.Volumes = append(spec.Volumes, k8sapiv1.Volume{
+		Name: configVolumeName,
+		VolumeSource: k8sapiv1.VolumeSource{
+			ConfigMap: &k8sapiv1.ConfigMapVolumeSource{
+				LocalObjectReference: k8sapiv1.LocalObjectReference{Name: configMapName(cluster)},
+				Items:                []k8sapiv1.KeyToPath{{Key: key, Path: configFileName}},
+			},
+		},
+	})
+	if len(spec.Containers) == 0 {
+		return
+	}
+	container := &spec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, k8sapiv1.VolumeMount{
+		Name:      configVolumeName,
+		MountPath: configMountPath,
+		ReadOnly:  true,
+	})
+	container.Args = append(container.Args, "--config-file", path.Join(configMountPath, configFileName))
+}
+
+// configMapName returns the name of the ConfigMap holding the configuration files of the cluster.
+func configMapName(cluster *v1.BallistaCluster) string {
+	return fmt.Sprintf("%s-config", cluster.Name)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	k8sapiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+var _ = Describe("BallistaCluster configuration", func() {
+	var cluster *v1.BallistaCluster
+
+	BeforeEach(func() {
+		cluster = &v1.BallistaCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"}}
+		cluster.Spec.Scheduler.Containers = []k8sapiv1.Container{{Name: "scheduler", Image: "ballista:0.5.0"}}
+		cluster.Spec.Executor.Containers = []k8sapiv1.Container{{Name: "executor", Image: "ballista:0.5.0"}}
+	})
+
+	It("renders the typed settings over the properties", func() {
+		logLevel := v1.LogLevel("debug")
+		workDir := "/data/ballista"
+		cluster.Spec.Executor.Config = &v1.ExecutorConfig{
+			LogLevel:        &logLevel,
+			ConcurrentTasks: int32Ptr(8),
+			WorkDir:         &workDir,
+		}
+		cluster.Spec.Executor.Properties = map[string]string{
+			"concurrent_tasks":      "2",
+			"plugin_dir":            "/plugins",
+			"ballista.with_stats":   "true",
+			"ballista.memory.ratio": "0.5",
+		}
+		Expect(executorConfig(cluster)).To(Equal(`"ballista.memory.ratio" = 0.5
+"ballista.with_stats" = true
+concurrent_tasks = 8
+log_level_setting = "debug"
+plugin_dir = "/plugins"
+work_dir = "/data/ballista"
+`))
+	})
+
+	It("renders the scheduler job defaults", func() {
+		cluster.Spec.Scheduler.Config = &v1.SchedulerConfig{ShufflePartitions: int32Ptr(16), BatchSize: int32Ptr(8192)}
+		Expect(schedulerConfig(cluster)).To(Equal(`"ballista.batch.size" = 8192
+"ballista.shuffle.partitions" = 16
+`))
+	})
+
+	It("mounts the configuration file into the Ballista container", func() {
+		template := schedulerPodTemplate(cluster)
+		Expect(template.Spec.Volumes).To(HaveLen(1))
+		Expect(template.Spec.Volumes[0].Name).To(Equal(configVolumeName))
+		Expect(template.Spec.Volumes[0].ConfigMap.Name).To(Equal("cluster-config"))
+		container := template.Spec.Containers[0]
+		Expect(container.VolumeMounts).To(ContainElement(k8sapiv1.VolumeMount{
+			Name:      configVolumeName,
+			MountPath: configMountPath,
+			ReadOnly:  true,
+		}))
+		Expect(container.Args).To(Equal([]string{"--config-file", "/etc/ballista/ballista.toml"}))
+	})
+
+	It("points the scheduler at the Kubernetes master", func() {
+		master := "https://10.0.0.1:6443"
+		cluster.Spec.Scheduler.KubernetesMaster = &master
+		Expect(schedulerPodTemplate(cluster).Spec.Containers[0].Env).To(ContainElement(
+			k8sapiv1.EnvVar{Name: kubernetesMasterEnv, Value: master}))
+
+		cluster.Spec.Scheduler.Containers[0].Env = []k8sapiv1.EnvVar{{Name: kubernetesMasterEnv, Value: "https://master"}}
+		Expect(schedulerPodTemplate(cluster).Spec.Containers[0].Env).To(Equal(cluster.Spec.Scheduler.Containers[0].Env))
+	})
+
+	It("rolls only the pods whose configuration changed", func() {
+		scheduler, executor := schedulerPodTemplate(cluster), executorPodTemplate(cluster)
+
+		cluster.Spec.Executor.Properties = map[string]string{"plugin_dir": "/plugins"}
+		Expect(schedulerPodTemplate(cluster).Annotations).To(Equal(scheduler.Annotations))
+		Expect(executorPodTemplate(cluster).Annotations[configChecksumAnnotation]).
+			NotTo(Equal(executor.Annotations[configChecksumAnnotation]))
+	})
+})
@@ -19,15 +19,31 @@ package controllers
 import (
 	"context"
 	"fmt"
-	"github.com/golang/glog"
+	"sort"
+	"strconv"
+	"time"
+
 	"github.com/google/uuid"
+	k8sapiv1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
-	k8sapiv1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
 	v1 "github.com/coderplay/ballista-operator/api/v1"
 )
 
@@ -35,11 +51,24 @@ import (
 type BallistaClusterReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	// SchedulerClient queries the schedulers of the clusters.
+	SchedulerClient SchedulerClient
+	// Recorder records events on the clusters.
+	Recorder record.EventRecorder
+	// Clock tells the time the clusters are reconciled at.
+	Clock clock.Clock
 }
 
 //+kubebuilder:rbac:groups=ballista.minzhou.info,resources=ballistaclusters,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=ballista.minzhou.info,resources=ballistaclusters/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=ballista.minzhou.info,resources=ballistaclusters/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -47,7 +76,6 @@ type BallistaClusterReconciler struct {
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.8.3/pkg/reconcile
 func (r *BallistaClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	var err error
 	log := log.FromContext(ctx)
 
 	var cluster = &v1.BallistaCluster{}
@@ -56,31 +84,88 @@ func (r *BallistaClusterReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	if !cluster.DeletionTimestamp.IsZero() {
+		return r.handleBallistaClusterDeletion(ctx, cluster)
+	}
+	if !controllerutil.ContainsFinalizer(cluster, ballistaClusterFinalizer) {
+		controllerutil.AddFinalizer(cluster, ballistaClusterFinalizer)
+		if err := r.Update(ctx, cluster); err != nil {
+			log.Error(err, "unable to add finalizer to BallistaCluster")
+			return ctrl.Result{}, err
+		}
+	}
+
+	// the whole reconcile happens at the same time
+	now := r.Clock.Now()
 	clusterCopy := cluster.DeepCopy()
+	pruneRecentPreemptions(clusterCopy, now)
+	operationRequested := acceptRequestedOperation(clusterCopy, metav1.NewTime(now))
+	if operationRequested {
+		r.Recorder.Eventf(cluster, k8sapiv1.EventTypeNormal, eventOperationRequested, "Accepted requested operation %q",
+			cluster.Annotations[v1.RequestedOperationAnnotation])
+	}
 
-	switch clusterCopy.Status.ClusterState.State {
-	case v1.NewState:
-		clusterCopy = r.startBallistaCluster(ctx, clusterCopy)
-	case v1.Pending, v1.RunningState, v1.UnknownState:
-		if err := r.getAndUpdateClusterState(ctx, clusterCopy); err != nil {
+	handler, ok := clusterStateHandlers[clusterCopy.Status.ClusterState.State]
+	if !ok {
+		handler = clusterStateHandlers[v1.UnknownState]
+	}
+	result, err := handler(r, ctx, clusterCopy, now)
+	if err != nil {
+		clusterCopy.Status.ClusterState.ErrorMessage = err.Error()
+		r.Recorder.Event(cluster, k8sapiv1.EventTypeWarning, eventReconcileError, err.Error())
+	}
+	clusterCopy.Status.DesiredExecutors = int32(defaultExecutorInstances(clusterCopy))
+	setClusterConditions(clusterCopy, err)
+	if state := clusterCopy.Status.ClusterState.State; state != cluster.Status.ClusterState.State && state == v1.Restarting {
+		r.Recorder.Event(cluster, k8sapiv1.EventTypeNormal, eventRestarting, "Deleting pods to restart the cluster")
+	}
+
+	if !equality.Semantic.DeepEqual(cluster.Status, clusterCopy.Status) {
+		if err := r.Status().Update(ctx, clusterCopy); err != nil {
+			log.Error(err, "unable to update BallistaCluster status")
 			return ctrl.Result{}, err
 		}
 	}
 
+	if operationRequested {
+		// the request is recorded in the status now, so it is done with
+		delete(clusterCopy.Annotations, v1.RequestedOperationAnnotation)
+		if err := r.Update(ctx, clusterCopy); err != nil {
+			log.Error(err, "unable to remove requested operation from BallistaCluster")
+			return ctrl.Result{}, err
+		}
+	}
 
-
-	return ctrl.Result{}, err
+	return result, err
 }
 
 var (
-	podOwnerKey         = ".metadata.controller"
-	podBallistaRoleKey  = "ballista-role"
-	apiGVStr            = v1.GroupVersion.String()
+	podOwnerKey        = ".metadata.controller"
+	podBallistaRoleKey = "ballista-role"
+	podClusterNameKey  = "ballista-cluster"
+	podClusterIDKey    = "ballista-cluster-id"
+	podTemplateHashKey = "ballista-template-hash"
+	podNodeKey         = ".spec.nodeName"
+	apiGVStr           = v1.GroupVersion.String()
 )
 
+const (
+	schedulerRole = "scheduler"
+	executorRole  = "executor"
+
+	// ballistaClusterFinalizer holds a BallistaCluster back from deletion until its resources are torn down.
+	ballistaClusterFinalizer = "ballista.minzhou.info/finalizer"
+	// terminationPollInterval is how often the teardown of a deleted cluster is checked.
+	terminationPollInterval = 5 * time.Second
+	// kubernetesMasterEnv is the environment variable the scheduler finds the Kubernetes master in.
+	kubernetesMasterEnv = "KUBERNETES_MASTER"
+)
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *BallistaClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Clock == nil {
+		r.Clock = clock.RealClock{}
+	}
 
 	// indexed by pod owner
 	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &k8sapiv1.Pod{}, podOwnerKey, func(rawObj client.Object) []string {
@@ -101,13 +186,13 @@ func (r *BallistaClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		return err
 	}
 
-	// indexed by pod role
-	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &k8sapiv1.Pod{}, podOwnerKey, func(rawObj client.Object) []string {
+	// indexed by node, to find the executors of a preempted node
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &k8sapiv1.Pod{}, podNodeKey, func(rawObj client.Object) []string {
 		pod := rawObj.(*k8sapiv1.Pod)
-		if podRole, ok := pod.Labels[podBallistaRoleKey]; ok {
-			return []string{podRole}
+		if pod.Spec.NodeName == "" {
+			return nil
 		}
-		return nil
+		return []string{pod.Spec.NodeName}
 	}); err != nil {
 		return err
 	}
@@ -115,86 +200,915 @@ func (r *BallistaClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1.BallistaCluster{}).
 		Owns(&k8sapiv1.Pod{}).
+		Owns(&k8sapiv1.Service{}).
+		Owns(&k8sapiv1.ConfigMap{}).
+		Owns(&k8sapiv1.PersistentVolumeClaim{}).
+		Owns(&policyv1beta1.PodDisruptionBudget{}).
+		Watches(&source.Kind{Type: &k8sapiv1.Node{}}, handler.EnqueueRequestsFromMapFunc(r.clustersOnNode),
+			builder.WithPredicates(nodePreemptionPredicate)).
 		Complete(r)
 }
 
-func (c *BallistaClusterReconciler) validateBallistaCluster(cluster *v1.BallistaCluster) error {
-	return nil
+// startBallistaCluster creates the scheduler service and pod of a new cluster and moves it to Pending.
+func (r *BallistaClusterReconciler) startBallistaCluster(ctx context.Context, cluster *v1.BallistaCluster, now time.Time) (ctrl.Result, error) {
+	if cluster.Status.ClusterID == "" {
+		// the identity is assigned once and survives restarts of the cluster
+		cluster.Status.ClusterID = uuid.New().String()
+	}
+	cluster.Status.WhenReadySince = nil
+	cluster.Status.TerminationTime = nil
+
+	if err := r.reconcileSchedulerService(ctx, cluster); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.reconcileConfigMap(ctx, cluster); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.reconcileStateVolumeClaim(ctx, cluster); err != nil {
+		return ctrl.Result{}, err
+	}
+	for i := 0; i < schedulerReplicas(cluster); i++ {
+		if err := r.createSchedulerPod(ctx, cluster, schedulerPodName(cluster, i)); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	cluster.Status.ClusterState = v1.ClusterState{State: v1.Pending}
+	return ctrl.Result{}, nil
 }
 
-func (r *BallistaClusterReconciler) startBallistaCluster(ctx context.Context, cluster *v1.BallistaCluster)  *v1.BallistaCluster {
+// createSchedulerPod creates the scheduler pod of the cluster with the given name. The pod names are derived
+// from the cluster name, so creating one again while it exists is harmless.
+func (r *BallistaClusterReconciler) createSchedulerPod(ctx context.Context, cluster *v1.BallistaCluster, name string) error {
 	log := log.FromContext(ctx)
-	clusterID := uuid.New().String()
 
-	schedulerName := fmt.Sprint("%s-%s", clusterID, "scheduler")
+	schedulerPod, err := r.newSchedulerPod(cluster, name)
+	if err != nil {
+		return err
+	}
+
+	// ...and create it on the cluster
+	if err := r.Create(ctx, schedulerPod); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			return nil
+		}
+		log.Error(err, "unable to create scheduler pod for Ballista Cluster", "scheduler", schedulerPod)
+		return err
+	}
+	r.Recorder.Eventf(cluster, k8sapiv1.EventTypeNormal, eventSchedulerCreated, "Created scheduler pod %s", schedulerPod.Name)
+	return nil
+}
+
+// newSchedulerPod builds a scheduler pod from the scheduler PodSpec.
+func (r *BallistaClusterReconciler) newSchedulerPod(cluster *v1.BallistaCluster, name string) (*k8sapiv1.Pod, error) {
+	template := schedulerPodTemplate(cluster)
+	labels := clusterLabels(cluster, schedulerRole)
+	labels[podTemplateHashKey] = podTemplateHash(&template)
+
 	schedulerPod := &k8sapiv1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
-			Labels:      make(map[string]string),
-			Annotations: make(map[string]string),
-			Name:        schedulerName,
+			Labels:      labels,
+			Annotations: template.Annotations,
+			Name:        name,
 			Namespace:   cluster.Namespace,
 		},
-		Spec: *cluster.Spec.Scheduler.PodSpec.DeepCopy(),
+		Spec: template.Spec,
+	}
+	if err := ctrl.SetControllerReference(cluster, schedulerPod, r.Scheme); err != nil {
+		return nil, err
 	}
+	return schedulerPod, nil
+}
 
-	// ...and create it on the cluster
-	if err := r.Create(ctx, schedulerPod); err != nil {
-		log.Error(err, "unable to create scheduler pod for Ballista Cluster", "scheduler", schedulerPod)
-		// return ctrl.Result{}, err
+// schedulerPodTemplate returns the template of the scheduler pod of the cluster.
+func schedulerPodTemplate(cluster *v1.BallistaCluster) k8sapiv1.PodTemplateSpec {
+	template := k8sapiv1.PodTemplateSpec{Spec: *cluster.Spec.Scheduler.PodSpec.DeepCopy()}
+	// the first container is the Ballista scheduler
+	if len(template.Spec.Containers) > 0 {
+		container := &template.Spec.Containers[0]
+		container.Ports = append(container.Ports, containerPorts(cluster.Spec.Scheduler.Ports)...)
+		if master := cluster.Spec.Scheduler.KubernetesMaster; master != nil && !hasEnv(container, kubernetesMasterEnv) {
+			container.Env = append(container.Env, k8sapiv1.EnvVar{Name: kubernetesMasterEnv, Value: *master})
+		}
+	}
+	mountConfig(cluster, &template, schedulerConfigKey, schedulerConfig(cluster))
+	mountStateBackend(cluster, &template)
+	if schedulerReplicas(cluster) > 1 {
+		spreadSchedulerReplicas(cluster, &template)
+	}
+	return template
+}
+
+// hasEnv tells whether the container sets the environment variable with the given name.
+func hasEnv(container *k8sapiv1.Container, name string) bool {
+	for _, env := range container.Env {
+		if env.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// reconcileSchedulerPods recreates the scheduler replicas that have gone missing, and removes those beyond
+// Spec.Scheduler.Replicas. A failed replica of a highly available scheduler is replaced while the others
+// serve.
+func (r *BallistaClusterReconciler) reconcileSchedulerPods(ctx context.Context, cluster *v1.BallistaCluster) error {
+	schedulers, err := r.listClusterPods(ctx, cluster, schedulerRole)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "unable to list scheduler pods")
+		return err
+	}
+
+	replicas := schedulerReplicas(cluster)
+	wanted := make(map[string]bool, replicas)
+	for i := 0; i < replicas; i++ {
+		wanted[schedulerPodName(cluster, i)] = true
+	}
+	existing := make(map[string]bool, len(schedulers))
+	for i := range schedulers {
+		scheduler := &schedulers[i]
+		existing[scheduler.Name] = true
+		failedReplica := replicas > 1 && !isPodActive(scheduler) &&
+			cluster.Status.SchedulerState == v1.SchedulerRunningState
+		if !wanted[scheduler.Name] || failedReplica {
+			if err := r.deleteIfNotDeleting(ctx, scheduler); err != nil {
+				return err
+			}
+		}
 	}
+	for i := 0; i < replicas; i++ {
+		if existing[schedulerPodName(cluster, i)] {
+			continue
+		}
+		if err := r.createSchedulerPod(ctx, cluster, schedulerPodName(cluster, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
+// observeBallistaCluster derives the cluster state from its pods and keeps the scheduler service, the
+// scheduler pod and the executors converged while the cluster is up.
+func (r *BallistaClusterReconciler) observeBallistaCluster(ctx context.Context, cluster *v1.BallistaCluster, now time.Time) (ctrl.Result, error) {
+	if err := r.getAndUpdateClusterState(ctx, cluster, now); err != nil {
+		return ctrl.Result{}, err
+	}
 
-	return cluster
+	switch cluster.Status.ClusterState.State {
+	case v1.Pending, v1.RunningState, v1.RestartWhenReadyState, v1.TerminateWhenReady, v1.UnknownState:
+		if err := r.reconcileSchedulerService(ctx, cluster); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.reconcileConfigMap(ctx, cluster); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.reconcileStateVolumeClaim(ctx, cluster); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.reconcileSchedulerPods(ctx, cluster); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.reconcileSchedulerDisruptionBudget(ctx, cluster); err != nil {
+			return ctrl.Result{}, err
+		}
+		upgradeRequeue, err := r.reconcileUpgrade(ctx, cluster, now)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		idleRequeue := r.reconcileIdleness(ctx, cluster, now)
+		autoscaleRequeue := r.autoscaleExecutors(ctx, cluster, now)
+		executorRequeue, err := r.reconcileExecutors(ctx, cluster, now)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		requeue := clusterStateRequeueInterval(cluster.Status.ClusterState.State)
+		for _, interval := range []time.Duration{upgradeRequeue, idleRequeue, autoscaleRequeue, executorRequeue} {
+			requeue = minRequeueInterval(requeue, interval)
+		}
+		return ctrl.Result{RequeueAfter: requeue}, nil
+	}
+	return ctrl.Result{RequeueAfter: clusterStateRequeueInterval(cluster.Status.ClusterState.State)}, nil
 }
 
-func (r *BallistaClusterReconciler)  handleBallistaClusterDeletion(ctx context.Context, cluster *v1.BallistaCluster)  {
-	log := log.FromContext(ctx)
-	// BallistaCluster deletion requested, lets delete scheduler pod and executor pods
-	if err := r.deleteBallistaResources(ctx, cluster); err != nil {
-		glog.Errorf("failed to delete resources associated with deleted SparkApplication %s/%s: %v", app.Namespace, app.Name, err)
+// restartBallistaCluster removes the pods of the cluster and starts it over once they are gone.
+func (r *BallistaClusterReconciler) restartBallistaCluster(ctx context.Context, cluster *v1.BallistaCluster, now time.Time) (ctrl.Result, error) {
+	deleted, err := r.deleteClusterPods(ctx, cluster)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !deleted {
+		return ctrl.Result{RequeueAfter: terminationPollInterval}, nil
 	}
+
+	resetSchedulerStatus(cluster)
+	resetExecutorStatus(cluster)
+	return r.startBallistaCluster(ctx, cluster, now)
+}
+
+// terminateBallistaCluster removes every resource owned by the cluster and moves it to Terminated once
+// they are gone.
+func (r *BallistaClusterReconciler) terminateBallistaCluster(ctx context.Context, cluster *v1.BallistaCluster, now time.Time) (ctrl.Result, error) {
+	deleted, err := r.deleteBallistaResources(ctx, cluster)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !deleted {
+		return ctrl.Result{RequeueAfter: terminationPollInterval}, nil
+	}
+
+	resetSchedulerStatus(cluster)
+	resetExecutorStatus(cluster)
+	cluster.Status.WhenReadySince = nil
+	cluster.Status.ClusterState = v1.ClusterState{State: v1.Terminated}
+	terminationTime := metav1.NewTime(now)
+	cluster.Status.TerminationTime = &terminationTime
+	r.Recorder.Event(cluster, k8sapiv1.EventTypeNormal, eventTerminated, "Deleted every resource of the cluster")
+	return ctrl.Result{}, nil
 }
 
-func (r *BallistaClusterReconciler)  deleteBallistaResources(ctx context.Context, cluster *v1.BallistaCluster) error {
+// handleBallistaClusterDeletion tears down a BallistaCluster that is being deleted, moving it through
+// Terminating to Terminated, and only then releases the finalizer.
+func (r *BallistaClusterReconciler) handleBallistaClusterDeletion(ctx context.Context, cluster *v1.BallistaCluster) (ctrl.Result, error) {
 	log := log.FromContext(ctx)
+	if !controllerutil.ContainsFinalizer(cluster, ballistaClusterFinalizer) {
+		return ctrl.Result{}, nil
+	}
 
-	var childPods = &k8sapiv1.PodList{}
-	if err := r.List(ctx, childPods, client.InNamespace(req.Namespace), client.MatchingFields{podOwnerKey: ""}); err != nil {
-		log.Error(err, "unable to list child Jobs")
+	clusterCopy := cluster.DeepCopy()
+	if clusterCopy.Status.ClusterState.State != v1.Terminated {
+		// BallistaCluster deletion requested, lets delete scheduler pod and executor pods
+		if clusterCopy.Status.ClusterState.State != v1.Terminating {
+			r.Recorder.Event(cluster, k8sapiv1.EventTypeNormal, eventTerminating, "Tearing down the deleted cluster")
+		}
+		clusterCopy.Status.ClusterState.State = v1.Terminating
+		result, err := r.terminateBallistaCluster(ctx, clusterCopy, r.Clock.Now())
+		if err != nil {
+			log.Error(err, "failed to delete resources associated with deleted BallistaCluster")
+			clusterCopy.Status.ClusterState.ErrorMessage = err.Error()
+		}
+		clusterCopy.Status.DesiredExecutors = int32(defaultExecutorInstances(clusterCopy))
+		setClusterConditions(clusterCopy, err)
+		if !equality.Semantic.DeepEqual(cluster.Status, clusterCopy.Status) {
+			if err := r.Status().Update(ctx, clusterCopy); err != nil {
+				log.Error(err, "unable to update BallistaCluster status")
+				return ctrl.Result{}, err
+			}
+		}
+		if err != nil || clusterCopy.Status.ClusterState.State != v1.Terminated {
+			return result, err
+		}
+	}
+
+	controllerutil.RemoveFinalizer(clusterCopy, ballistaClusterFinalizer)
+	if err := r.Update(ctx, clusterCopy); err != nil {
+		log.Error(err, "unable to remove finalizer from BallistaCluster")
 		return ctrl.Result{}, err
 	}
+	return ctrl.Result{}, nil
+}
+
+// deleteBallistaResources drains the executors, then deletes the scheduler and every other resource owned by
+// the cluster. It returns true once nothing owned by the cluster is left.
+func (r *BallistaClusterReconciler) deleteBallistaResources(ctx context.Context, cluster *v1.BallistaCluster) (bool, error) {
+	log := log.FromContext(ctx)
 
+	if deleted, err := r.deleteClusterPods(ctx, cluster); err != nil || !deleted {
+		return false, err
+	}
 
-	r.Delete()
+	remaining := 0
+	for _, list := range ownedResourceLists() {
+		if err := r.List(ctx, list, client.InNamespace(cluster.Namespace),
+			client.MatchingLabels{podClusterNameKey: cluster.Name}); err != nil {
+			log.Error(err, "unable to list owned resources")
+			return false, err
+		}
+		objs, err := meta.ExtractList(list)
+		if err != nil {
+			return false, err
+		}
+		for _, obj := range objs {
+			owned, ok := obj.(client.Object)
+			if !ok || !metav1.IsControlledBy(owned, cluster) {
+				continue
+			}
+			remaining++
+			if err := r.deleteIfNotDeleting(ctx, owned); err != nil {
+				return false, err
+			}
+		}
+	}
+	return remaining == 0, nil
 }
 
-func (r *BallistaClusterReconciler) getAndUpdateClusterState(ctx context.Context, cluster *v1.BallistaCluster) error {
+// deleteClusterPods drains the executors, then deletes the scheduler. It returns true once no pod owned
+// by the cluster is left.
+func (r *BallistaClusterReconciler) deleteClusterPods(ctx context.Context, cluster *v1.BallistaCluster) (bool, error) {
+	log := log.FromContext(ctx)
+
+	// executors go first so that they stop taking tasks before the scheduler disappears
+	for _, role := range []string{executorRole, schedulerRole} {
+		pods, err := r.listClusterPods(ctx, cluster, role)
+		if err != nil {
+			log.Error(err, "unable to list child Pods", "role", role)
+			return false, err
+		}
+		if len(pods) == 0 {
+			continue
+		}
+		for i := range pods {
+			if err := r.deleteIfNotDeleting(ctx, &pods[i]); err != nil {
+				return false, err
+			}
+		}
+		log.Info("waiting for pods to terminate", "role", role, "remaining", len(pods))
+		return false, nil
+	}
+	return true, nil
+}
+
+// ownedResourceLists returns empty lists of every kind, other than pods, that a cluster may own.
+func ownedResourceLists() []client.ObjectList {
+	return []client.ObjectList{
+		&k8sapiv1.ServiceList{},
+		&k8sapiv1.ConfigMapList{},
+		&k8sapiv1.PersistentVolumeClaimList{},
+		&policyv1beta1.PodDisruptionBudgetList{},
+	}
+}
+
+// deleteIfNotDeleting deletes the object unless its deletion is already underway.
+func (r *BallistaClusterReconciler) deleteIfNotDeleting(ctx context.Context, obj client.Object) error {
+	if obj.GetDeletionTimestamp() != nil {
+		return nil
+	}
+	if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+		log.FromContext(ctx).Error(err, "unable to delete owned resource", "name", obj.GetName())
+		return err
+	}
+	return nil
+}
+
+// getAndUpdateClusterState refreshes the scheduler and executor states and moves the cluster to the state
+// they imply.
+func (r *BallistaClusterReconciler) getAndUpdateClusterState(ctx context.Context, cluster *v1.BallistaCluster, now time.Time) error {
 	if err := r.getAndUpdateSchedulerState(ctx, cluster); err != nil {
 		return err
 	}
 	if err := r.getAndUpdateExecutorState(ctx, cluster); err != nil {
 		return err
 	}
+
+	state := cluster.Status.ClusterState.State
+	idle := false
+	if state == v1.RestartWhenReadyState || state == v1.TerminateWhenReady {
+		idle = r.isClusterIdle(ctx, cluster, now)
+	}
+	cluster.Status.ClusterState.State = nextClusterState(state, cluster.Status.SchedulerState,
+		int(totalExecutors(cluster).ReadyExecutors), executorInstances(cluster), idle)
 	return nil
 }
 
+// isClusterIdle tells whether a cluster waiting to restart or terminate may proceed: its scheduler runs no
+// jobs, or the jobs had Spec.WhenReadyTimeoutSeconds to finish.
+func (r *BallistaClusterReconciler) isClusterIdle(ctx context.Context, cluster *v1.BallistaCluster, now time.Time) bool {
+	log := log.FromContext(ctx)
 
+	if whenReadyTimedOut(cluster, now) {
+		log.Info("timed out waiting for running jobs", "state", cluster.Status.ClusterState.State)
+		return true
+	}
+	switch cluster.Status.SchedulerState {
+	case v1.SchedulerPendingState:
+		// no job runs without a scheduler
+		return true
+	case v1.SchedulerRunningState:
+		if r.SchedulerClient == nil {
+			return false
+		}
+		jobs, err := r.SchedulerClient.ActiveJobs(ctx, schedulerEndpoint(cluster))
+		if err != nil {
+			log.Error(err, "unable to query scheduler jobs", "endpoint", schedulerEndpoint(cluster))
+			return false
+		}
+		log.V(1).Info("waiting for running jobs", "jobs", jobs)
+		return jobs == 0
+	default:
+		return false
+	}
+}
+
+// getAndUpdateSchedulerState records the state of every scheduler replica and of the scheduler as a whole,
+// along with an error message when no replica can be found or runs and one has terminated.
 func (r *BallistaClusterReconciler) getAndUpdateSchedulerState(ctx context.Context, cluster *v1.BallistaCluster) error {
-	var pod = &k8sapiv1.PodSpec{}
-	r.List(ctx, pod )
+	log := log.FromContext(ctx)
+
+	schedulers, err := r.listClusterPods(ctx, cluster, schedulerRole)
+	if err != nil {
+		log.Error(err, "unable to list scheduler pods")
+		return err
+	}
+	if len(schedulers) == 0 {
+		// the pods may just not have reached the cache yet, so only the next observations can tell
+		resetSchedulerStatus(cluster)
+		cluster.Status.SchedulerState = v1.SchedulerUnknownState
+		cluster.Status.ClusterState.ErrorMessage = "scheduler pod not found"
+		return nil
+	}
+
+	replicas := make([]v1.SchedulerReplicaStatus, 0, len(schedulers))
+	ready := 0
+	for i := range schedulers {
+		replica := schedulerReplicaStatus(&schedulers[i])
+		if replica.Ready {
+			ready++
+		}
+		replicas = append(replicas, replica)
+	}
+	sort.Slice(replicas, func(i, j int) bool { return replicas[i].Name < replicas[j].Name })
+	cluster.Status.Schedulers = replicas
+	cluster.Status.ReadySchedulers = int32(ready)
+
+	previousState := cluster.Status.SchedulerState
+	state, message := aggregateSchedulerState(replicas)
+	cluster.Status.SchedulerState = state
+	switch state {
+	case v1.SchedulerFailedState, v1.SchedulerCompletedState:
+		cluster.Status.ClusterState.ErrorMessage = message
+		if previousState != state {
+			r.Recorder.Event(cluster, k8sapiv1.EventTypeWarning, eventSchedulerFailed, message)
+		}
+	default:
+		cluster.Status.ClusterState.ErrorMessage = ""
+	}
 	return nil
 }
 
+// getAndUpdateExecutorState records the state of every executor pod of the cluster, dropping the entries of
+// pods that are being deleted or no longer exist, and counts the executors by state, for the cluster and
+// for every executor pool of the spec.
 func (r *BallistaClusterReconciler) getAndUpdateExecutorState(ctx context.Context, cluster *v1.BallistaCluster) error {
+	log := log.FromContext(ctx)
+
+	executors, err := r.listClusterPods(ctx, cluster, executorRole)
+	if err != nil {
+		log.Error(err, "unable to list executor pods")
+		return err
+	}
+
+	var executorState map[string]v1.ExecutorState
+	var clusterCounts v1.ExecutorPoolStatus
+	poolStatus := make(map[string]*v1.ExecutorPoolStatus, len(cluster.Spec.ExecutorPools))
+	var poolStatuses []v1.ExecutorPoolStatus
+	for _, pool := range executorPools(cluster) {
+		if pool.name == "" {
+			continue
+		}
+		poolStatuses = append(poolStatuses, v1.ExecutorPoolStatus{
+			Name:             pool.name,
+			DesiredExecutors: int32(pool.instances),
+			FallbackActive:   pool.fallbackActive,
+		})
+	}
+	for i := range poolStatuses {
+		poolStatus[poolStatuses[i].Name] = &poolStatuses[i]
+	}
+	for i := range executors {
+		executor := &executors[i]
+		if executor.DeletionTimestamp != nil {
+			continue
+		}
+		if executorState == nil {
+			executorState = make(map[string]v1.ExecutorState, len(executors))
+		}
+		state := podToExecutorState(executor)
+		if isPodActive(executor) && isExecutorDecommissioning(executor) {
+			state = v1.ExecutorDecommissioningState
+		}
+		// preempted executors are reported as such once they are replaced
+		if state == v1.ExecutorFailedState && cluster.Status.ExecutorState[executor.Name] != v1.ExecutorFailedState &&
+			!isExecutorPreempted(executor) {
+			r.Recorder.Eventf(cluster, k8sapiv1.EventTypeWarning, eventExecutorFailed, "Executor pod %s failed: %s",
+				executor.Name, executorFailureMessage(executor))
+		}
+		executorState[executor.Name] = state
+		// the executors of Spec.Executor count for the cluster, those of a pool for the pool alone, and those
+		// of removed pools for neither
+		var counts *v1.ExecutorPoolStatus
+		if name, ok := executor.Labels[podExecutorPoolKey]; ok {
+			if counts, ok = poolStatus[name]; !ok {
+				continue
+			}
+		} else {
+			counts = &clusterCounts
+		}
+		switch state {
+		case v1.ExecutorRunningState:
+			counts.ReadyExecutors++
+		case v1.ExecutorPendingState:
+			counts.PendingExecutors++
+		case v1.ExecutorFailedState:
+			counts.FailedExecutors++
+		}
+		if isPodActive(executor) && state != v1.ExecutorDecommissioningState {
+			counts.Executors++
+		}
+	}
+	cluster.Status.ExecutorState = executorState
+	cluster.Status.ReadyExecutors = clusterCounts.ReadyExecutors
+	cluster.Status.PendingExecutors = clusterCounts.PendingExecutors
+	cluster.Status.FailedExecutors = clusterCounts.FailedExecutors
+	cluster.Status.Executors = clusterCounts.Executors
+	cluster.Status.ExecutorPools = poolStatuses
+	// the scale subresource scales Spec.Executor alone, so the selector leaves the pools out
+	selector := labels.SelectorFromSet(roleSelector(cluster, executorRole))
+	withoutPool, err := labels.NewRequirement(podExecutorPoolKey, selection.DoesNotExist, nil)
+	if err != nil {
+		return err
+	}
+	cluster.Status.ExecutorSelector = selector.Add(*withoutPool).String()
 	return nil
 }
 
-func (r *BallistaClusterReconciler) schedulerStateToClusterState()  {
+// reconcileExecutors creates executor pods, or decommissions some, until the number of serving executors
+// owned by the cluster matches the desired instances of every executor pool, decommissioning the executors
+// of pools removed from the spec. Executors are only created once the scheduler is running. It returns how
+// long to wait before checking decommissioning executors again.
+func (r *BallistaClusterReconciler) reconcileExecutors(ctx context.Context, cluster *v1.BallistaCluster, now time.Time) (time.Duration, error) {
+	log := log.FromContext(ctx)
+
+	executors, err := r.listClusterPods(ctx, cluster, executorRole)
+	if err != nil {
+		log.Error(err, "unable to list executor pods")
+		return 0, err
+	}
+	// the names of preempted executors stay taken until they are gone
+	taken := make(map[string]bool, len(executors))
+	for i := range executors {
+		taken[executors[i].Name] = true
+	}
+	executors, preemptionRequeue, err := r.reconcilePreemptedExecutors(ctx, cluster, executors, now)
+	if err != nil {
+		return 0, err
+	}
+	pools, byPool := groupExecutorsByPool(cluster, executors)
+	serving := make(map[string][]k8sapiv1.Pod, len(pools))
+	var decommissioning []k8sapiv1.Pod
+	needRegistered := false
+	for _, pool := range pools {
+		for _, executor := range byPool[pool.name] {
+			switch {
+			case !isPodActive(&executor):
+			case isExecutorDecommissioning(&executor):
+				decommissioning = append(decommissioning, executor)
+			default:
+				serving[pool.name] = append(serving[pool.name], executor)
+			}
+		}
+		if len(serving[pool.name]) > pool.instances {
+			needRegistered = true
+		}
+	}
+
+	var registered map[string]SchedulerExecutor
+	if len(decommissioning) > 0 || needRegistered {
+		registered = r.registeredExecutors(ctx, cluster)
+	}
 
+	requeue := preemptionRequeue
+	if len(decommissioning) > 0 {
+		waiting, err := r.reapDecommissionedExecutors(ctx, cluster, decommissioning, registered,
+			r.isSchedulerIdle(ctx, cluster), now)
+		if err != nil {
+			return 0, err
+		}
+		if waiting {
+			requeue = minRequeueInterval(requeue, decommissionPollInterval)
+		}
+	}
+
+	// executors are named after the lowest free index, so that creating one the cache does not show yet
+	// fails rather than doubling it
+	created := 0
+	defer func() {
+		if created > 0 {
+			r.Recorder.Eventf(cluster, k8sapiv1.EventTypeNormal, eventExecutorsCreated, "Created %d executor pods", created)
+		}
+	}()
+	for _, pool := range pools {
+		if excess := len(serving[pool.name]) - pool.instances; excess > 0 {
+			log.Info("scaling down executors", "pool", pool.name, "from", len(serving[pool.name]), "to", pool.instances)
+			victims := executorsToRemove(serving[pool.name], excess, registered)
+			if err := r.decommissionExecutors(ctx, cluster, victims, registered, "on scale-down", now); err != nil {
+				return 0, err
+			}
+			requeue = minRequeueInterval(requeue, decommissionPollInterval)
+			continue
+		}
+
+		if cluster.Status.SchedulerState != v1.SchedulerRunningState {
+			log.V(1).Info("waiting for the scheduler to run before creating executors")
+			continue
+		}
+		index := 0
+		for i := len(serving[pool.name]); i < pool.instances; i++ {
+			for taken[executorPodName(cluster, pool.name, index)] {
+				index++
+			}
+			name := executorPodName(cluster, pool.name, index)
+			taken[name] = true
+
+			executorPod, err := r.newExecutorPod(cluster, pool.name, name)
+			if err != nil {
+				return 0, err
+			}
+			if err := r.Create(ctx, executorPod); err != nil {
+				if apierrors.IsAlreadyExists(err) {
+					continue
+				}
+				log.Error(err, "unable to create executor pod for Ballista Cluster", "executor", executorPod)
+				return 0, err
+			}
+			created++
+		}
+	}
+	return requeue, nil
 }
 
+// newExecutorPod builds an executor pod of the pool with the given name from the PodSpec of the pool, pointing
+// it at the scheduler.
+func (r *BallistaClusterReconciler) newExecutorPod(cluster *v1.BallistaCluster, pool string, name string) (*k8sapiv1.Pod, error) {
+	template := executorPoolPodTemplate(cluster, pool)
+	labels := make(map[string]string, len(template.Labels)+4)
+	for key, value := range template.Labels {
+		labels[key] = value
+	}
+	for key, value := range clusterLabels(cluster, executorRole) {
+		labels[key] = value
+	}
+	labels[podTemplateHashKey] = podTemplateHash(&template)
 
+	executorPod := &k8sapiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      labels,
+			Annotations: template.Annotations,
+			Name:        name,
+			Namespace:   cluster.Namespace,
+		},
+		Spec: template.Spec,
+	}
+	if err := ctrl.SetControllerReference(cluster, executorPod, r.Scheme); err != nil {
+		return nil, err
+	}
+	return executorPod, nil
+}
 
+// executorPodTemplate returns the template of the executor pods of Spec.Executor.
+func executorPodTemplate(cluster *v1.BallistaCluster) k8sapiv1.PodTemplateSpec {
+	return newExecutorPodTemplate(cluster, &cluster.Spec.Executor.PodSpec)
+}
 
+// newExecutorPodTemplate returns a template of executor pods running the PodSpec, with the ports and the
+// configuration of Spec.Executor.
+func newExecutorPodTemplate(cluster *v1.BallistaCluster, spec *k8sapiv1.PodSpec) k8sapiv1.PodTemplateSpec {
+	template := k8sapiv1.PodTemplateSpec{Spec: *spec.DeepCopy()}
+	// the first container is the Ballista executor
+	if len(template.Spec.Containers) > 0 {
+		container := &template.Spec.Containers[0]
+		container.Ports = append(container.Ports, containerPorts(cluster.Spec.Executor.Ports)...)
+		container.Env = append(container.Env,
+			k8sapiv1.EnvVar{Name: "BALLISTA_EXECUTOR_SCHEDULER_HOST", Value: schedulerServiceHost(cluster)},
+			k8sapiv1.EnvVar{Name: "BALLISTA_EXECUTOR_SCHEDULER_PORT", Value: strconv.Itoa(int(schedulerPort(cluster)))},
+			k8sapiv1.EnvVar{
+				Name: "BALLISTA_EXECUTOR_EXTERNAL_HOST",
+				ValueFrom: &k8sapiv1.EnvVarSource{
+					FieldRef: &k8sapiv1.ObjectFieldSelector{FieldPath: "status.podIP"},
+				},
+			},
+		)
+	}
+	mountConfig(cluster, &template, executorConfigKey, executorConfig(cluster))
+	return template
+}
 
+// reconcileSchedulerService creates or updates the headless service executors and clients use to
+// reach the scheduler, and publishes its endpoint in the cluster status.
+func (r *BallistaClusterReconciler) reconcileSchedulerService(ctx context.Context, cluster *v1.BallistaCluster) error {
+	log := log.FromContext(ctx)
 
+	service := &k8sapiv1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      schedulerServiceName(cluster),
+			Namespace: cluster.Namespace,
+		},
+	}
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, service, func() error {
+		if service.Labels == nil {
+			service.Labels = make(map[string]string)
+		}
+		for key, value := range clusterLabels(cluster, schedulerRole) {
+			service.Labels[key] = value
+		}
+		if service.Annotations == nil {
+			service.Annotations = make(map[string]string)
+		}
+		for key, value := range cluster.Spec.Scheduler.ServiceAnnotations {
+			service.Annotations[key] = value
+		}
+		service.Spec.ClusterIP = k8sapiv1.ClusterIPNone
+		service.Spec.Selector = roleSelector(cluster, schedulerRole)
+		service.Spec.Ports = schedulerServicePorts(cluster)
+		return ctrl.SetControllerReference(cluster, service, r.Scheme)
+	})
+	if err != nil {
+		log.Error(err, "unable to reconcile scheduler service for Ballista Cluster", "service", service.Name)
+		return err
+	}
+	if result != controllerutil.OperationResultNone {
+		log.Info("reconciled scheduler service", "service", service.Name, "result", result)
+	}
+
+	cluster.Status.SchedulerEndpoint = schedulerEndpoint(cluster)
+	return nil
+}
+
+// schedulerServicePorts returns the service ports exposing the scheduler, defaulting to the scheduler gRPC port.
+func schedulerServicePorts(cluster *v1.BallistaCluster) []k8sapiv1.ServicePort {
+	ports := cluster.Spec.Scheduler.Ports
+	if len(ports) == 0 {
+		ports = []v1.Port{{Name: schedulerRole, Protocol: string(k8sapiv1.ProtocolTCP), ContainerPort: v1.DefaultSchedulerPort}}
+	}
+	var result []k8sapiv1.ServicePort
+	for _, port := range ports {
+		result = append(result, k8sapiv1.ServicePort{
+			Name:       port.Name,
+			Protocol:   portProtocol(port),
+			Port:       port.ContainerPort,
+			TargetPort: intstr.FromInt(int(port.ContainerPort)),
+		})
+	}
+	return result
+}
+
+// schedulerServiceName returns the name of the scheduler service of the cluster.
+func schedulerServiceName(cluster *v1.BallistaCluster) string {
+	return fmt.Sprintf("%s-%s", cluster.Name, schedulerRole)
+}
+
+// schedulerPodName returns the name of the scheduler pod of the cluster with the given index. The first
+// replica is named after the cluster alone, as the scheduler of a cluster with a single replica is.
+func schedulerPodName(cluster *v1.BallistaCluster, index int) string {
+	if index == 0 {
+		return fmt.Sprintf("%s-%s", cluster.Name, schedulerRole)
+	}
+	return fmt.Sprintf("%s-%s-%d", cluster.Name, schedulerRole, index)
+}
+
+// executorPodName returns the name of the executor pod of the cluster in the pool with the given name and
+// with the given index. The executors of Spec.Executor are named after the cluster alone.
+func executorPodName(cluster *v1.BallistaCluster, pool string, index int) string {
+	if pool == "" {
+		return fmt.Sprintf("%s-%s-%d", cluster.Name, executorRole, index)
+	}
+	return fmt.Sprintf("%s-%s-%s-%d", cluster.Name, executorRole, pool, index)
+}
+
+// schedulerServiceHost returns the DNS name of the scheduler service of the cluster.
+func schedulerServiceHost(cluster *v1.BallistaCluster) string {
+	return fmt.Sprintf("%s.%s.svc", schedulerServiceName(cluster), cluster.Namespace)
+}
+
+// schedulerEndpoint returns the host and port of the scheduler service of the cluster.
+func schedulerEndpoint(cluster *v1.BallistaCluster) string {
+	return fmt.Sprintf("%s:%d", schedulerServiceHost(cluster), schedulerPort(cluster))
+}
+
+// listClusterPods lists the pods controlled by the cluster that play the given role.
+func (r *BallistaClusterReconciler) listClusterPods(ctx context.Context, cluster *v1.BallistaCluster, role string) ([]k8sapiv1.Pod, error) {
+	var pods = &k8sapiv1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(cluster.Namespace),
+		client.MatchingFields{podOwnerKey: cluster.Name},
+		client.MatchingLabels{podBallistaRoleKey: role}); err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+// roleSelector returns the labels selecting the pods of the given role in the cluster.
+func roleSelector(cluster *v1.BallistaCluster, role string) map[string]string {
+	return map[string]string{
+		podClusterNameKey:  cluster.Name,
+		podBallistaRoleKey: role,
+	}
+}
+
+// clusterLabels returns the labels of a resource of the given role in the cluster, which include the
+// cluster identity once it is assigned.
+func clusterLabels(cluster *v1.BallistaCluster, role string) map[string]string {
+	labels := roleSelector(cluster, role)
+	if cluster.Status.ClusterID != "" {
+		labels[podClusterIDKey] = cluster.Status.ClusterID
+	}
+	return labels
+}
+
+// isPodReady tells whether a pod is ready to serve.
+func isPodReady(pod *k8sapiv1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == k8sapiv1.PodReady {
+			return condition.Status == k8sapiv1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// isPodActive tells whether a pod is neither terminated nor being deleted.
+func isPodActive(pod *k8sapiv1.Pod) bool {
+	return pod.DeletionTimestamp == nil &&
+		pod.Status.Phase != k8sapiv1.PodSucceeded &&
+		pod.Status.Phase != k8sapiv1.PodFailed
+}
+
+// schedulerReplicas returns the desired number of scheduler replicas, defaulting to one.
+func schedulerReplicas(cluster *v1.BallistaCluster) int {
+	if cluster.Spec.Scheduler.Replicas == nil {
+		return int(v1.DefaultSchedulerReplicas)
+	}
+	return int(*cluster.Spec.Scheduler.Replicas)
+}
+
+// executorInstances returns the desired number of executors of the cluster, across all its executor pools.
+func executorInstances(cluster *v1.BallistaCluster) int {
+	instances := 0
+	for _, pool := range executorPools(cluster) {
+		instances += pool.instances
+	}
+	return instances
+}
+
+// totalExecutors returns the executor counts of the cluster across Spec.Executor and its executor pools, as
+// reported in its status.
+func totalExecutors(cluster *v1.BallistaCluster) v1.ExecutorPoolStatus {
+	status := &cluster.Status
+	total := v1.ExecutorPoolStatus{
+		Executors:        status.Executors,
+		ReadyExecutors:   status.ReadyExecutors,
+		PendingExecutors: status.PendingExecutors,
+		FailedExecutors:  status.FailedExecutors,
+	}
+	for _, pool := range status.ExecutorPools {
+		total.Executors += pool.Executors
+		total.ReadyExecutors += pool.ReadyExecutors
+		total.PendingExecutors += pool.PendingExecutors
+		total.FailedExecutors += pool.FailedExecutors
+	}
+	return total
+}
+
+// defaultExecutorInstances returns the desired number of executors of Spec.Executor: none for a suspended
+// cluster, the number the autoscaler decided on for an autoscaled cluster, Spec.Executor.Instances otherwise,
+// defaulting to one, or to none when the cluster has executor pools.
+func defaultExecutorInstances(cluster *v1.BallistaCluster) int {
+	if isClusterSuspended(cluster) {
+		return 0
+	}
+	if cluster.Spec.Executor.Autoscaling != nil && cluster.Status.Autoscaling != nil {
+		return int(cluster.Status.Autoscaling.DesiredExecutors)
+	}
+	if cluster.Spec.Executor.Instances == nil {
+		if len(cluster.Spec.ExecutorPools) > 0 {
+			return 0
+		}
+		return 1
+	}
+	return int(*cluster.Spec.Executor.Instances)
+}
+
+// schedulerPort returns the port executors use to reach the scheduler.
+func schedulerPort(cluster *v1.BallistaCluster) int32 {
+	if len(cluster.Spec.Scheduler.Ports) > 0 {
+		return cluster.Spec.Scheduler.Ports[0].ContainerPort
+	}
+	return v1.DefaultSchedulerPort
+}
+
+// containerPorts converts the Ballista port settings into container ports.
+func containerPorts(ports []v1.Port) []k8sapiv1.ContainerPort {
+	var result []k8sapiv1.ContainerPort
+	for _, port := range ports {
+		result = append(result, k8sapiv1.ContainerPort{
+			Name:          port.Name,
+			Protocol:      portProtocol(port),
+			ContainerPort: port.ContainerPort,
+		})
+	}
+	return result
+}
+
+// portProtocol returns the protocol of the port, defaulting to TCP like the Kubernetes API server does.
+func portProtocol(port v1.Port) k8sapiv1.Protocol {
+	if port.Protocol == "" {
+		return k8sapiv1.ProtocolTCP
+	}
+	return k8sapiv1.Protocol(port.Protocol)
+}
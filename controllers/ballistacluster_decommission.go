@@ -0,0 +1,226 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	k8sapiv1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+const (
+	// decommissionStartedAnnotation marks an executor pod removed on scale-down with the time, in RFC 3339,
+	// its decommissioning started.
+	decommissionStartedAnnotation = "ballista.minzhou.info/decommission-started"
+	// executorStoppedAnnotation marks a decommissioning executor pod the scheduler was told to stop assigning
+	// tasks to with the ID the executor registered with.
+	executorStoppedAnnotation = "ballista.minzhou.info/executor-stopped"
+	// decommissionPollInterval is how often decommissioning executors are checked for remaining tasks.
+	decommissionPollInterval = 10 * time.Second
+)
+
+// decommissionExecutors starts decommissioning the executors for the given reason: they are marked as such,
+// and the scheduler is told to stop assigning them tasks. The registered executors are keyed by host, nil when
+// unknown.
+func (r *BallistaClusterReconciler) decommissionExecutors(ctx context.Context, cluster *v1.BallistaCluster, executors []k8sapiv1.Pod, registered map[string]SchedulerExecutor, reason string, now time.Time) error {
+	log := log.FromContext(ctx)
+
+	started := now.UTC().Format(time.RFC3339)
+	for i := range executors {
+		executor := &executors[i]
+		patch := client.MergeFrom(executor.DeepCopy())
+		if executor.Annotations == nil {
+			executor.Annotations = make(map[string]string)
+		}
+		executor.Annotations[decommissionStartedAnnotation] = started
+		if err := r.Patch(ctx, executor, patch); client.IgnoreNotFound(err) != nil {
+			log.Error(err, "unable to mark executor pod as decommissioning", "executor", executor.Name)
+			return err
+		}
+		log.Info("decommissioning executor", "executor", executor.Name, "reason", reason)
+		r.Recorder.Eventf(cluster, k8sapiv1.EventTypeNormal, eventExecutorDecommissioning,
+			"Decommissioning executor pod %s %s", executor.Name, reason)
+
+		if err := r.stopExecutor(ctx, cluster, executor, registered); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stopExecutor tells the scheduler to stop assigning tasks to a decommissioning executor registered with it,
+// and marks the executor as stopped once the scheduler knows. A scheduler that cannot be told is told again
+// when the decommissioning executors are next reaped.
+func (r *BallistaClusterReconciler) stopExecutor(ctx context.Context, cluster *v1.BallistaCluster, executor *k8sapiv1.Pod, registered map[string]SchedulerExecutor) error {
+	log := log.FromContext(ctx)
+
+	if _, stopped := executor.Annotations[executorStoppedAnnotation]; stopped || r.SchedulerClient == nil {
+		return nil
+	}
+	schedulerExecutor, ok := registered[executor.Status.PodIP]
+	if !ok {
+		return nil
+	}
+	if err := r.SchedulerClient.StopExecutor(ctx, schedulerEndpoint(cluster), schedulerExecutor.ID); err != nil {
+		log.Error(err, "unable to stop executor on the scheduler", "executor", executor.Name, "executorId", schedulerExecutor.ID)
+		return nil
+	}
+	patch := client.MergeFrom(executor.DeepCopy())
+	executor.Annotations[executorStoppedAnnotation] = schedulerExecutor.ID
+	if err := r.Patch(ctx, executor, patch); client.IgnoreNotFound(err) != nil {
+		log.Error(err, "unable to mark executor pod as stopped", "executor", executor.Name)
+		return err
+	}
+	return nil
+}
+
+// reapDecommissionedExecutors stops the decommissioning executors the scheduler was not told of yet, deletes
+// those that are done with their tasks or out of grace, and forgets them in the status. The registered
+// executors are keyed by host, nil when unknown, and idle tells whether the scheduler is known to run no
+// jobs. It returns whether executors are left to wait for.
+func (r *BallistaClusterReconciler) reapDecommissionedExecutors(ctx context.Context, cluster *v1.BallistaCluster, executors []k8sapiv1.Pod, registered map[string]SchedulerExecutor, idle bool, now time.Time) (bool, error) {
+	log := log.FromContext(ctx)
+
+	gracePeriod := decommissionGracePeriod(cluster)
+	waiting := false
+	for i := range executors {
+		executor := &executors[i]
+		if err := r.stopExecutor(ctx, cluster, executor, registered); err != nil {
+			return waiting, err
+		}
+		if !executorDecommissioned(executor, registered, idle, gracePeriod, now) {
+			waiting = true
+			continue
+		}
+		log.Info("removing decommissioned executor", "executor", executor.Name)
+		if err := r.deleteIfNotDeleting(ctx, executor); err != nil {
+			return waiting, err
+		}
+		delete(cluster.Status.ExecutorState, executor.Name)
+		r.Recorder.Eventf(cluster, k8sapiv1.EventTypeNormal, eventExecutorRemoved, "Removed decommissioned executor pod %s",
+			executor.Name)
+	}
+	return waiting, nil
+}
+
+// registeredExecutors returns the executors registered with the scheduler of the cluster keyed by host, or nil
+// when the scheduler cannot tell.
+func (r *BallistaClusterReconciler) registeredExecutors(ctx context.Context, cluster *v1.BallistaCluster) map[string]SchedulerExecutor {
+	if r.SchedulerClient == nil || cluster.Status.SchedulerState != v1.SchedulerRunningState {
+		return nil
+	}
+	executors, err := r.SchedulerClient.Executors(ctx, schedulerEndpoint(cluster))
+	if err != nil {
+		log.FromContext(ctx).Error(err, "unable to list executors registered with the scheduler")
+		return nil
+	}
+	registered := make(map[string]SchedulerExecutor, len(executors))
+	for _, executor := range executors {
+		registered[executor.Host] = executor
+	}
+	return registered
+}
+
+// executorDecommissioned tells whether a decommissioning executor may be deleted: it never got to run, or its
+// grace period is over, or the scheduler was told to stop assigning it tasks and runs no jobs, or it is not
+// registered with the scheduler and was never stopped, so that it holds no tasks.
+func executorDecommissioned(pod *k8sapiv1.Pod, registered map[string]SchedulerExecutor, idle bool, gracePeriod time.Duration, now time.Time) bool {
+	if pod.Status.Phase != k8sapiv1.PodRunning {
+		return true
+	}
+	started, err := time.Parse(time.RFC3339, pod.Annotations[decommissionStartedAnnotation])
+	if err != nil || !started.Add(gracePeriod).After(now) {
+		return true
+	}
+	if _, stopped := pod.Annotations[executorStoppedAnnotation]; stopped {
+		// the scheduler forgets a stopped executor, which may still run the tasks of the jobs
+		return idle
+	}
+	return !isExecutorRegistered(pod, registered)
+}
+
+// isExecutorRegistered tells whether the executor is registered with the scheduler, and may thus hold tasks.
+// The registered executors are keyed by host, nil when unknown, in which case any executor may be.
+func isExecutorRegistered(pod *k8sapiv1.Pod, registered map[string]SchedulerExecutor) bool {
+	if registered == nil {
+		return true
+	}
+	_, ok := registered[pod.Status.PodIP]
+	return ok
+}
+
+// isSchedulerIdle tells whether the scheduler of the cluster is known to run no jobs, in which case no
+// executor holds tasks or shuffle partitions that are still needed.
+func (r *BallistaClusterReconciler) isSchedulerIdle(ctx context.Context, cluster *v1.BallistaCluster) bool {
+	if r.SchedulerClient == nil || cluster.Status.SchedulerState != v1.SchedulerRunningState {
+		return false
+	}
+	jobs, err := r.SchedulerClient.ActiveJobs(ctx, schedulerEndpoint(cluster))
+	if err != nil {
+		log.FromContext(ctx).Error(err, "unable to query scheduler jobs", "endpoint", schedulerEndpoint(cluster))
+		return false
+	}
+	return jobs == 0
+}
+
+// isExecutorDecommissioning tells whether an executor pod is being decommissioned.
+func isExecutorDecommissioning(pod *k8sapiv1.Pod) bool {
+	_, ok := pod.Annotations[decommissionStartedAnnotation]
+	return ok
+}
+
+// executorsToRemove picks the given number of executors to remove when scaling down: those that are not
+// running yet first, as they hold no work, then those the scheduler does not know, then the newest. The
+// registered executors are keyed by host, nil when unknown.
+func executorsToRemove(executors []k8sapiv1.Pod, count int, registered map[string]SchedulerExecutor) []k8sapiv1.Pod {
+	idle := func(pod *k8sapiv1.Pod) bool {
+		return !isExecutorRegistered(pod, registered)
+	}
+
+	candidates := make([]k8sapiv1.Pod, len(executors))
+	copy(candidates, executors)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		iRunning := podToExecutorState(&candidates[i]) == v1.ExecutorRunningState
+		jRunning := podToExecutorState(&candidates[j]) == v1.ExecutorRunningState
+		if iRunning != jRunning {
+			return !iRunning
+		}
+		if iIdle, jIdle := idle(&candidates[i]), idle(&candidates[j]); iIdle != jIdle {
+			return iIdle
+		}
+		return candidates[j].CreationTimestamp.Before(&candidates[i].CreationTimestamp)
+	})
+	if count > len(candidates) {
+		count = len(candidates)
+	}
+	return candidates[:count]
+}
+
+// decommissionGracePeriod returns how long a decommissioning executor may keep running its tasks.
+func decommissionGracePeriod(cluster *v1.BallistaCluster) time.Duration {
+	seconds := v1.DefaultDecommissionGracePeriodSeconds
+	if cluster.Spec.Executor.DecommissionGracePeriodSeconds != nil {
+		seconds = *cluster.Spec.Executor.DecommissionGracePeriodSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	k8sapiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+var _ = Describe("BallistaCluster executor decommissioning", func() {
+	now := time.Now()
+
+	executor := func(name string, ip string, age time.Duration, phase k8sapiv1.PodPhase) k8sapiv1.Pod {
+		return k8sapiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(now.Add(-age))},
+			Status: k8sapiv1.PodStatus{
+				Phase:             phase,
+				PodIP:             ip,
+				ContainerStatuses: []k8sapiv1.ContainerStatus{{Ready: true}},
+			},
+		}
+	}
+
+	DescribeTable("executorsToRemove",
+		func(count int, registered map[string]SchedulerExecutor, expected []string) {
+			executors := []k8sapiv1.Pod{
+				executor("old", "10.0.0.1", time.Hour, k8sapiv1.PodRunning),
+				executor("new", "10.0.0.2", time.Minute, k8sapiv1.PodRunning),
+				executor("pending", "", 2*time.Hour, k8sapiv1.PodPending),
+			}
+			var names []string
+			for _, pod := range executorsToRemove(executors, count, registered) {
+				names = append(names, pod.Name)
+			}
+			Expect(names).To(Equal(expected))
+		},
+		Entry("not running, then newest", 2, nil, []string{"pending", "new"}),
+		Entry("not running, then unregistered", 2, map[string]SchedulerExecutor{
+			"10.0.0.2": {ID: "b"},
+		}, []string{"pending", "old"}),
+		Entry("no more than there are", 5, nil, []string{"pending", "new", "old"}),
+	)
+
+	DescribeTable("executorDecommissioned",
+		func(phase k8sapiv1.PodPhase, decommissioning time.Duration, stopped bool, registered map[string]SchedulerExecutor, idle bool, expected bool) {
+			pod := executor("executor", "10.0.0.1", time.Hour, phase)
+			pod.Annotations = map[string]string{
+				decommissionStartedAnnotation: now.Add(-decommissioning).UTC().Format(time.RFC3339),
+			}
+			if stopped {
+				pod.Annotations[executorStoppedAnnotation] = "a"
+			}
+			Expect(executorDecommissioned(&pod, registered, idle, time.Minute, now)).To(Equal(expected))
+		},
+		Entry("never ran", k8sapiv1.PodPending, time.Second, false, nil, false, true),
+		Entry("scheduler unknown", k8sapiv1.PodRunning, time.Second, false, nil, true, false),
+		Entry("registered but not stopped", k8sapiv1.PodRunning, time.Second, false, map[string]SchedulerExecutor{"10.0.0.1": {ID: "a"}}, true, false),
+		Entry("stopped while jobs run", k8sapiv1.PodRunning, time.Second, true, map[string]SchedulerExecutor{}, false, false),
+		Entry("stopped and scheduler idle", k8sapiv1.PodRunning, time.Second, true, map[string]SchedulerExecutor{}, true, true),
+		Entry("never registered", k8sapiv1.PodRunning, time.Second, false, map[string]SchedulerExecutor{}, false, true),
+		Entry("out of grace", k8sapiv1.PodRunning, 2*time.Minute, false, map[string]SchedulerExecutor{"10.0.0.1": {ID: "a"}}, false, true),
+	)
+
+	Context("with the scheduler", func() {
+		var ctx context.Context
+		var cluster *v1.BallistaCluster
+		var scheduler *fakeSchedulerClient
+		var r *BallistaClusterReconciler
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			cluster = &v1.BallistaCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"}}
+			cluster.Status.SchedulerState = v1.SchedulerRunningState
+			scheduler = &fakeSchedulerClient{executors: []SchedulerExecutor{{ID: "a", Host: "10.0.0.1"}}}
+
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			pod := executor("executor", "10.0.0.1", time.Hour, k8sapiv1.PodRunning)
+			pod.Namespace = "default"
+			r = &BallistaClusterReconciler{
+				Client:          fake.NewClientBuilder().WithScheme(scheme).WithObjects(&pod).Build(),
+				Scheme:          scheme,
+				Recorder:        record.NewFakeRecorder(20),
+				SchedulerClient: scheduler,
+				Clock:           clock.NewFakeClock(time.Now()),
+			}
+		})
+
+		pod := func() (k8sapiv1.Pod, bool) {
+			var pod k8sapiv1.Pod
+			err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "executor"}, &pod)
+			if apierrors.IsNotFound(err) {
+				return pod, false
+			}
+			Expect(err).NotTo(HaveOccurred())
+			return pod, true
+		}
+
+		// reap reaps the executor, as still registered while the scheduler runs the given number of jobs.
+		reap := func(activeJobs int) {
+			scheduler.activeJobs = activeJobs
+			executor, _ := pod()
+			_, err := r.reapDecommissionedExecutors(ctx, cluster, []k8sapiv1.Pod{executor},
+				r.registeredExecutors(ctx, cluster), r.isSchedulerIdle(ctx, cluster), r.Clock.Now())
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		It("stops an executor on the scheduler before deleting it", func() {
+			executor, _ := pod()
+			Expect(r.decommissionExecutors(ctx, cluster, []k8sapiv1.Pod{executor}, r.registeredExecutors(ctx, cluster),
+				"on scale-down", r.Clock.Now())).To(Succeed())
+			Expect(scheduler.stopped).To(Equal([]string{"a"}))
+			executor, _ = pod()
+			Expect(executor.Annotations).To(HaveKeyWithValue(executorStoppedAnnotation, "a"))
+
+			reap(1)
+			_, found := pod()
+			Expect(found).To(BeTrue())
+
+			reap(0)
+			_, found = pod()
+			Expect(found).To(BeFalse())
+			Expect(scheduler.stopped).To(Equal([]string{"a"}))
+		})
+
+		It("deletes a stopped executor once its grace period passed on the clock", func() {
+			fakeClock := r.Clock.(*clock.FakeClock)
+			cluster.Spec.Executor.DecommissionGracePeriodSeconds = int32Ptr(60)
+			executor, _ := pod()
+			Expect(r.decommissionExecutors(ctx, cluster, []k8sapiv1.Pod{executor}, r.registeredExecutors(ctx, cluster),
+				"on scale-down", fakeClock.Now())).To(Succeed())
+
+			fakeClock.Step(59 * time.Second)
+			reap(1)
+			_, found := pod()
+			Expect(found).To(BeTrue())
+
+			fakeClock.Step(time.Second)
+			reap(1)
+			_, found = pod()
+			Expect(found).To(BeFalse())
+		})
+
+		It("keeps an executor the scheduler could not be told of until it is", func() {
+			scheduler.err = errors.New("unavailable")
+			executor, _ := pod()
+			Expect(r.decommissionExecutors(ctx, cluster, []k8sapiv1.Pod{executor}, map[string]SchedulerExecutor{
+				"10.0.0.1": {ID: "a", Host: "10.0.0.1"},
+			}, "on scale-down", r.Clock.Now())).To(Succeed())
+			executor, _ = pod()
+			Expect(executor.Annotations).NotTo(HaveKey(executorStoppedAnnotation))
+
+			scheduler.err = nil
+			scheduler.stopped = nil
+			reap(1)
+			Expect(scheduler.stopped).To(Equal([]string{"a"}))
+			executor, found := pod()
+			Expect(found).To(BeTrue())
+			Expect(executor.Annotations).To(HaveKeyWithValue(executorStoppedAnnotation, "a"))
+		})
+	})
+})
@@ -0,0 +1,91 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+// Reasons of the events recorded on a BallistaCluster.
+const (
+	// eventSchedulerCreated is recorded when the scheduler pod is created.
+	eventSchedulerCreated = "SchedulerCreated"
+	// eventSchedulerFailed is recorded when the scheduler pod fails or completes.
+	eventSchedulerFailed = "SchedulerFailed"
+	// eventExecutorsCreated is recorded when executor pods are created.
+	eventExecutorsCreated = "ExecutorsCreated"
+	// eventExecutorDecommissioning is recorded when an executor starts decommissioning on scale-down or on the
+	// preemption of its node.
+	eventExecutorDecommissioning = "ExecutorDecommissioning"
+	// eventExecutorRemoved is recorded when a decommissioned executor is deleted.
+	eventExecutorRemoved = "ExecutorRemoved"
+	// eventExecutorFailed is recorded when an executor fails or cannot start.
+	eventExecutorFailed = "ExecutorFailed"
+	// eventExecutorPreempted is recorded when an executor is replaced because it was evicted or its node is
+	// preempted.
+	eventExecutorPreempted = "ExecutorPreempted"
+	// eventExecutorPoolFallback is recorded when the executors of a pool move to its fallback pool.
+	eventExecutorPoolFallback = "ExecutorPoolFallback"
+	// eventExecutorsAutoscaled is recorded when the autoscaler changes the number of executors.
+	eventExecutorsAutoscaled = "ExecutorsAutoscaled"
+	// eventUpgradeStarted is recorded when a rolling upgrade starts.
+	eventUpgradeStarted = "UpgradeStarted"
+	// eventUpgradeCompleted is recorded when a rolling upgrade completes.
+	eventUpgradeCompleted = "UpgradeCompleted"
+	// eventUpgradeFailed is recorded when a rolling upgrade halts.
+	eventUpgradeFailed = "UpgradeFailed"
+	// eventSuspended is recorded when an idle cluster is suspended.
+	eventSuspended = "Suspended"
+	// eventResumed is recorded when a suspended cluster resumes.
+	eventResumed = "Resumed"
+	// eventOperationRequested is recorded when a requested operation is accepted.
+	eventOperationRequested = "OperationRequested"
+	// eventRestarting is recorded when the pods of a cluster are deleted to restart it.
+	eventRestarting = "Restarting"
+	// eventTerminating is recorded when the teardown of a cluster starts.
+	eventTerminating = "Terminating"
+	// eventTerminated is recorded when every resource of a cluster is gone.
+	eventTerminated = "Terminated"
+	// eventIdleTTLExpired is recorded when an ephemeral cluster idle for its TTL starts terminating.
+	eventIdleTTLExpired = "IdleTTLExpired"
+	// eventFinishedTTLExpired is recorded when a terminated ephemeral cluster is deleted.
+	eventFinishedTTLExpired = "FinishedTTLExpired"
+	// eventReconcileError is recorded when reconciling a cluster fails.
+	eventReconcileError = "ReconcileError"
+)
+
+// Reasons of the events recorded on a BallistaQuery.
+const (
+	// eventQuerySubmitted is recorded when a query is submitted to the scheduler.
+	eventQuerySubmitted = "QuerySubmitted"
+	// eventQuerySucceeded is recorded when the job of a query completes.
+	eventQuerySucceeded = "QuerySucceeded"
+	// eventQueryFailed is recorded when a query fails or cannot be submitted.
+	eventQueryFailed = "QueryFailed"
+	// eventQueryCancelled is recorded when the job of a deleted query is cancelled.
+	eventQueryCancelled = "QueryCancelled"
+)
+
+// Reasons of the events recorded on a BallistaScheduledQuery.
+const (
+	// eventRunCreated is recorded when the query of a scheduled run is created.
+	eventRunCreated = "RunCreated"
+	// eventRunSkipped is recorded when a run is skipped as the previous one hasn't finished yet.
+	eventRunSkipped = "RunSkipped"
+	// eventRunReplaced is recorded when a run in progress is deleted to make way for the next one.
+	eventRunReplaced = "RunReplaced"
+	// eventMissedSchedule is recorded when a run missed its starting deadline.
+	eventMissedSchedule = "MissedSchedule"
+	// eventInvalidSchedule is recorded when the schedule cannot be parsed.
+	eventInvalidSchedule = "InvalidSchedule"
+)
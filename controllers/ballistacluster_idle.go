@@ -0,0 +1,111 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	k8sapiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+// idlePollInterval is how often the scheduler of a cluster with an idle timeout is checked for jobs.
+const idlePollInterval = 30 * time.Second
+
+// reconcileIdleness suspends a cluster whose scheduler ran no jobs for Spec.IdleTimeoutSeconds, and resumes
+// it once a job is submitted. An ephemeral cluster idle for Spec.TTLSecondsAfterIdle is terminated instead.
+// It returns how long to wait before checking the scheduler again, zero when the cluster has neither an
+// idle timeout nor an idle TTL.
+func (r *BallistaClusterReconciler) reconcileIdleness(ctx context.Context, cluster *v1.BallistaCluster, now time.Time) time.Duration {
+	log := log.FromContext(ctx)
+
+	if cluster.Spec.IdleTimeoutSeconds == nil && isClusterSuspended(cluster) {
+		resumeCluster(cluster, v1.IdleTimeoutDisabledReason, "idle timeout is no longer set")
+	}
+	if cluster.Spec.IdleTimeoutSeconds == nil && cluster.Spec.TTLSecondsAfterIdle == nil {
+		cluster.Status.IdleSince = nil
+		return 0
+	}
+	if r.SchedulerClient == nil || cluster.Status.SchedulerState != v1.SchedulerRunningState {
+		return idlePollInterval
+	}
+
+	jobs, err := r.SchedulerClient.ActiveJobs(ctx, schedulerEndpoint(cluster))
+	if err != nil {
+		log.Error(err, "unable to query scheduler jobs", "endpoint", schedulerEndpoint(cluster))
+		return idlePollInterval
+	}
+	if jobs > 0 {
+		cluster.Status.IdleSince = nil
+		if isClusterSuspended(cluster) {
+			log.Info("resuming idle cluster", "jobs", jobs)
+			resumeCluster(cluster, v1.JobSubmittedReason, fmt.Sprintf("%d jobs submitted", jobs))
+			r.Recorder.Eventf(cluster, k8sapiv1.EventTypeNormal, eventResumed, "Resumed the cluster for %d submitted jobs", jobs)
+		}
+		return idlePollInterval
+	}
+
+	if cluster.Status.IdleSince == nil {
+		idleSince := metav1.NewTime(now)
+		cluster.Status.IdleSince = &idleSince
+	}
+	if r.expireIdleCluster(ctx, cluster, now) {
+		return idlePollInterval
+	}
+	if cluster.Spec.IdleTimeoutSeconds == nil {
+		return idlePollInterval
+	}
+	timeout := time.Duration(*cluster.Spec.IdleTimeoutSeconds) * time.Second
+	if !isClusterSuspended(cluster) && !cluster.Status.IdleSince.Add(timeout).After(now) {
+		log.Info("suspending idle cluster", "idleSince", cluster.Status.IdleSince)
+		meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
+			Type:               v1.SuspendedCondition,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: cluster.Generation,
+			Reason:             v1.IdleTimeoutReason,
+			Message:            fmt.Sprintf("no jobs for %s, executors are scaled to zero", timeout),
+		})
+		r.Recorder.Eventf(cluster, k8sapiv1.EventTypeNormal, eventSuspended, "Suspended the cluster after %s without jobs", timeout)
+	}
+	return idlePollInterval
+}
+
+// resumeCluster lifts the suspension of the cluster, if any, for the given reason, and restarts its idle clock.
+func resumeCluster(cluster *v1.BallistaCluster, reason string, message string) {
+	cluster.Status.IdleSince = nil
+	if meta.FindStatusCondition(cluster.Status.Conditions, v1.SuspendedCondition) == nil {
+		return
+	}
+	meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
+		Type:               v1.SuspendedCondition,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: cluster.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// isClusterSuspended tells whether the executors of the cluster are scaled to zero for idleness.
+func isClusterSuspended(cluster *v1.BallistaCluster) bool {
+	return meta.IsStatusConditionTrue(cluster.Status.Conditions, v1.SuspendedCondition)
+}
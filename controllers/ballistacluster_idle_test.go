@@ -0,0 +1,115 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/record"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+var _ = Describe("BallistaCluster idle timeout", func() {
+	var cluster *v1.BallistaCluster
+	var scheduler *fakeSchedulerClient
+	var recorder *record.FakeRecorder
+	var r *BallistaClusterReconciler
+
+	BeforeEach(func() {
+		cluster = &v1.BallistaCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"}}
+		cluster.Spec.Executor.Instances = int32Ptr(3)
+		cluster.Spec.IdleTimeoutSeconds = int32Ptr(60)
+		cluster.Status.SchedulerState = v1.SchedulerRunningState
+		scheduler = &fakeSchedulerClient{}
+		recorder = record.NewFakeRecorder(10)
+		r = &BallistaClusterReconciler{SchedulerClient: scheduler, Recorder: recorder, Clock: clock.NewFakeClock(time.Now())}
+	})
+
+	idleFor := func(idle time.Duration) {
+		since := metav1.NewTime(r.Clock.Now().Add(-idle))
+		cluster.Status.IdleSince = &since
+	}
+
+	It("starts the idle clock", func() {
+		r.reconcileIdleness(context.Background(), cluster, r.Clock.Now())
+		Expect(cluster.Status.IdleSince).NotTo(BeNil())
+		Expect(isClusterSuspended(cluster)).To(BeFalse())
+		Expect(executorInstances(cluster)).To(Equal(3))
+	})
+
+	It("suspends a cluster idle for too long", func() {
+		idleFor(2 * time.Minute)
+		r.reconcileIdleness(context.Background(), cluster, r.Clock.Now())
+		Expect(isClusterSuspended(cluster)).To(BeTrue())
+		Expect(executorInstances(cluster)).To(BeZero())
+		Expect(recorder.Events).To(Receive(ContainSubstring(eventSuspended)))
+	})
+
+	It("suspends once the idle timeout passed on the clock", func() {
+		fakeClock := r.Clock.(*clock.FakeClock)
+		r.reconcileIdleness(context.Background(), cluster, fakeClock.Now())
+		Expect(cluster.Status.IdleSince.Time).To(Equal(fakeClock.Now()))
+
+		fakeClock.Step(59 * time.Second)
+		r.reconcileIdleness(context.Background(), cluster, fakeClock.Now())
+		Expect(isClusterSuspended(cluster)).To(BeFalse())
+
+		fakeClock.Step(time.Second)
+		r.reconcileIdleness(context.Background(), cluster, fakeClock.Now())
+		Expect(isClusterSuspended(cluster)).To(BeTrue())
+	})
+
+	It("resumes once a job is submitted", func() {
+		idleFor(2 * time.Minute)
+		r.reconcileIdleness(context.Background(), cluster, r.Clock.Now())
+
+		scheduler.activeJobs = 1
+		r.reconcileIdleness(context.Background(), cluster, r.Clock.Now())
+		Expect(isClusterSuspended(cluster)).To(BeFalse())
+		Expect(meta.FindStatusCondition(cluster.Status.Conditions, v1.SuspendedCondition).Reason).To(Equal(v1.JobSubmittedReason))
+		Expect(cluster.Status.IdleSince).To(BeNil())
+		Expect(executorInstances(cluster)).To(Equal(3))
+		Expect(recorder.Events).To(Receive(ContainSubstring(eventSuspended)))
+		Expect(recorder.Events).To(Receive(ContainSubstring(eventResumed)))
+	})
+
+	It("resumes on request", func() {
+		idleFor(2 * time.Minute)
+		r.reconcileIdleness(context.Background(), cluster, r.Clock.Now())
+
+		cluster.Annotations = map[string]string{v1.RequestedOperationAnnotation: v1.ResumeOperation}
+		Expect(acceptRequestedOperation(cluster, metav1.Now())).To(BeTrue())
+		Expect(isClusterSuspended(cluster)).To(BeFalse())
+		Expect(meta.FindStatusCondition(cluster.Status.Conditions, v1.SuspendedCondition).Reason).To(Equal(v1.ResumeRequestedReason))
+	})
+
+	It("resumes once the idle timeout is unset", func() {
+		idleFor(2 * time.Minute)
+		r.reconcileIdleness(context.Background(), cluster, r.Clock.Now())
+
+		cluster.Spec.IdleTimeoutSeconds = nil
+		Expect(r.reconcileIdleness(context.Background(), cluster, r.Clock.Now())).To(BeZero())
+		Expect(isClusterSuspended(cluster)).To(BeFalse())
+	})
+})
@@ -0,0 +1,135 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sort"
+
+	k8sapiv1 "k8s.io/api/core/v1"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+// podExecutorPoolKey labels the executor pods of an executor pool with the name of the pool. The executors of
+// Spec.Executor carry no such label.
+const podExecutorPoolKey = "ballista-executor-pool"
+
+// executorPool is a group of executors reconciled on its own: the executors of Spec.Executor, whose pool has
+// no name, or those of one of Spec.ExecutorPools.
+type executorPool struct {
+	name      string
+	instances int
+	// fallbackActive tells whether the instances of the pool moved to its fallback pool.
+	fallbackActive bool
+}
+
+// executorPools returns the pools of executors of the cluster, the executors of Spec.Executor first. The
+// instances of a pool whose executors are preempted too often are added to its fallback pool.
+func executorPools(cluster *v1.BallistaCluster) []executorPool {
+	pools := []executorPool{{instances: defaultExecutorInstances(cluster)}}
+	index := make(map[string]int, len(cluster.Spec.ExecutorPools))
+	for i := range cluster.Spec.ExecutorPools {
+		pool := &cluster.Spec.ExecutorPools[i]
+		index[pool.Name] = len(pools)
+		pools = append(pools, executorPool{name: pool.Name, instances: executorPoolInstances(cluster, pool)})
+	}
+
+	moved := make([]int, len(pools))
+	for i := range cluster.Spec.ExecutorPools {
+		pool := &cluster.Spec.ExecutorPools[i]
+		if !isFallbackActive(cluster, pool) {
+			continue
+		}
+		fallback, ok := index[pool.Fallback.PoolName]
+		if !ok {
+			continue
+		}
+		current := &pools[index[pool.Name]]
+		moved[fallback] += current.instances
+		current.instances = 0
+		current.fallbackActive = true
+	}
+	for i := range pools {
+		pools[i].instances += moved[i]
+	}
+	return pools
+}
+
+// executorPoolSpec returns the executor pool of the spec with the given name, or nil when there is none.
+func executorPoolSpec(cluster *v1.BallistaCluster, name string) *v1.ExecutorPool {
+	for i := range cluster.Spec.ExecutorPools {
+		if cluster.Spec.ExecutorPools[i].Name == name {
+			return &cluster.Spec.ExecutorPools[i]
+		}
+	}
+	return nil
+}
+
+// executorPoolInstances returns the desired number of executors of the pool: none for a suspended cluster,
+// Instances otherwise, defaulting to one.
+func executorPoolInstances(cluster *v1.BallistaCluster, pool *v1.ExecutorPool) int {
+	if isClusterSuspended(cluster) {
+		return 0
+	}
+	if pool.Instances == nil {
+		return int(v1.DefaultExecutorPoolInstances)
+	}
+	return int(*pool.Instances)
+}
+
+// executorPoolPodTemplate returns the template of the executor pods of the pool with the given name, which
+// is the template of Spec.Executor for the pool without a name.
+func executorPoolPodTemplate(cluster *v1.BallistaCluster, name string) k8sapiv1.PodTemplateSpec {
+	pool := executorPoolSpec(cluster, name)
+	if pool == nil {
+		return executorPodTemplate(cluster)
+	}
+	template := newExecutorPodTemplate(cluster, &pool.PodSpec)
+	template.Labels = make(map[string]string, len(pool.Labels)+1)
+	for key, value := range pool.Labels {
+		template.Labels[key] = value
+	}
+	template.Labels[podExecutorPoolKey] = pool.Name
+	return template
+}
+
+// groupExecutorsByPool groups the executor pods by the name of their pool, adding a pool to drain for every
+// pool the pods belong to that is no longer in the cluster spec. The pools are returned in the order of
+// executorPools, followed by the pools to drain in name order.
+func groupExecutorsByPool(cluster *v1.BallistaCluster, executors []k8sapiv1.Pod) ([]executorPool, map[string][]k8sapiv1.Pod) {
+	pools := executorPools(cluster)
+	known := make(map[string]bool, len(pools))
+	for _, pool := range pools {
+		known[pool.name] = true
+	}
+
+	byPool := make(map[string][]k8sapiv1.Pod, len(pools))
+	var removed []string
+	for i := range executors {
+		name := executors[i].Labels[podExecutorPoolKey]
+		if !known[name] {
+			known[name] = true
+			removed = append(removed, name)
+		}
+		byPool[name] = append(byPool[name], executors[i])
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		pools = append(pools, executorPool{name: name})
+	}
+	return pools, byPool
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	k8sapiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+var _ = Describe("BallistaCluster executor pools", func() {
+	var ctx context.Context
+	var cluster *v1.BallistaCluster
+	var r *BallistaClusterReconciler
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		cluster = &v1.BallistaCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default", UID: "cluster-uid"}}
+		cluster.Spec.Executor.Instances = int32Ptr(2)
+		cluster.Spec.Executor.Containers = []k8sapiv1.Container{{Name: v1.ExecutorContainerName, Image: "ballista:0.5.0"}}
+		cluster.Spec.ExecutorPools = []v1.ExecutorPool{{
+			Name: "spot",
+			PodSpec: k8sapiv1.PodSpec{
+				Containers:   []k8sapiv1.Container{{Name: v1.ExecutorContainerName, Image: "ballista:0.5.0"}},
+				NodeSelector: map[string]string{"node-lifecycle": "spot"},
+				Tolerations:  []k8sapiv1.Toleration{{Key: "spot", Operator: k8sapiv1.TolerationOpExists}},
+			},
+			Instances: int32Ptr(3),
+			Labels:    map[string]string{"cost-center": "batch"},
+		}}
+		cluster.Status.SchedulerState = v1.SchedulerRunningState
+
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(v1.AddToScheme(scheme)).To(Succeed())
+		r = &BallistaClusterReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster).Build(),
+			Scheme:   scheme,
+			Recorder: record.NewFakeRecorder(10),
+			Clock:    clock.NewFakeClock(time.Now()),
+		}
+	})
+
+	executors := func() map[string]k8sapiv1.Pod {
+		pods, err := r.listClusterPods(ctx, cluster, executorRole)
+		Expect(err).NotTo(HaveOccurred())
+		byName := make(map[string]k8sapiv1.Pod, len(pods))
+		for _, pod := range pods {
+			byName[pod.Name] = pod
+		}
+		return byName
+	}
+
+	It("counts the executors of every pool", func() {
+		Expect(executorInstances(cluster)).To(Equal(5))
+
+		meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
+			Type:   v1.SuspendedCondition,
+			Status: metav1.ConditionTrue,
+			Reason: "Idle",
+		})
+		Expect(executorInstances(cluster)).To(BeZero())
+	})
+
+	It("runs no executors of Spec.Executor by default when the cluster has pools", func() {
+		cluster.Spec.Executor.Instances = nil
+		Expect(defaultExecutorInstances(cluster)).To(BeZero())
+		Expect(executorInstances(cluster)).To(Equal(3))
+	})
+
+	It("builds the pod template of a pool from its PodSpec", func() {
+		template := executorPoolPodTemplate(cluster, "spot")
+		Expect(template.Spec.NodeSelector).To(Equal(map[string]string{"node-lifecycle": "spot"}))
+		Expect(template.Spec.Tolerations).To(HaveLen(1))
+		Expect(template.Labels).To(Equal(map[string]string{"cost-center": "batch", podExecutorPoolKey: "spot"}))
+		Expect(template.Spec.Containers[0].Env).NotTo(BeEmpty())
+
+		defaultTemplate := executorPoolPodTemplate(cluster, "")
+		Expect(defaultTemplate).To(Equal(executorPodTemplate(cluster)))
+	})
+
+	It("creates the executors of every pool", func() {
+		_, err := r.reconcileExecutors(ctx, cluster, r.Clock.Now())
+		Expect(err).NotTo(HaveOccurred())
+
+		pods := executors()
+		Expect(pods).To(HaveLen(5))
+		Expect(pods).To(HaveKey("cluster-executor-0"))
+		Expect(pods["cluster-executor-0"].Labels).NotTo(HaveKey(podExecutorPoolKey))
+		spot := pods["cluster-executor-spot-2"]
+		Expect(spot.Labels).To(HaveKeyWithValue(podExecutorPoolKey, "spot"))
+		Expect(spot.Labels).To(HaveKeyWithValue("cost-center", "batch"))
+		Expect(spot.Labels).To(HaveKeyWithValue(podBallistaRoleKey, executorRole))
+		Expect(spot.Spec.NodeSelector).To(HaveKeyWithValue("node-lifecycle", "spot"))
+	})
+
+	It("decommissions the executors of a removed pool", func() {
+		_, err := r.reconcileExecutors(ctx, cluster, r.Clock.Now())
+		Expect(err).NotTo(HaveOccurred())
+
+		cluster.Spec.ExecutorPools = nil
+		requeue, err := r.reconcileExecutors(ctx, cluster, r.Clock.Now())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeue).To(Equal(decommissionPollInterval))
+		for name, pod := range executors() {
+			Expect(isExecutorDecommissioning(&pod)).To(Equal(pod.Labels[podExecutorPoolKey] == "spot"), name)
+		}
+	})
+
+	It("reports the executors of every pool", func() {
+		_, err := r.reconcileExecutors(ctx, cluster, r.Clock.Now())
+		Expect(err).NotTo(HaveOccurred())
+		for _, pod := range executors() {
+			pod.Status.Phase = k8sapiv1.PodPending
+			if pod.Name == "cluster-executor-spot-0" || pod.Name == "cluster-executor-0" {
+				pod.Status = k8sapiv1.PodStatus{
+					Phase:             k8sapiv1.PodRunning,
+					ContainerStatuses: []k8sapiv1.ContainerStatus{{Ready: true}},
+				}
+			}
+			Expect(r.Status().Update(ctx, &pod)).To(Succeed())
+		}
+
+		Expect(r.getAndUpdateExecutorState(ctx, cluster)).To(Succeed())
+		Expect(cluster.Status.ReadyExecutors).To(Equal(int32(1)))
+		Expect(cluster.Status.ExecutorPools).To(Equal([]v1.ExecutorPoolStatus{{
+			Name:             "spot",
+			DesiredExecutors: 3,
+			Executors:        3,
+			ReadyExecutors:   1,
+			PendingExecutors: 2,
+		}}))
+		Expect(totalExecutors(cluster).ReadyExecutors).To(Equal(int32(2)))
+
+		// the scale subresource selects the executors of Spec.Executor alone
+		selector, err := labels.Parse(cluster.Status.ExecutorSelector)
+		Expect(err).NotTo(HaveOccurred())
+		var selected []string
+		for _, pod := range executors() {
+			if selector.Matches(labels.Set(pod.Labels)) {
+				selected = append(selected, pod.Name)
+			}
+		}
+		Expect(selected).To(ConsistOf("cluster-executor-0", "cluster-executor-1"))
+		Expect(cluster.Status.Executors).To(Equal(int32(len(selected))))
+	})
+
+	It("upgrades the executors of a pool whose PodSpec changed", func() {
+		_, err := r.reconcileExecutors(ctx, cluster, r.Clock.Now())
+		Expect(err).NotTo(HaveOccurred())
+		var pods []k8sapiv1.Pod
+		for _, pod := range executors() {
+			pods = append(pods, pod)
+		}
+
+		cluster.Spec.ExecutorPools[0].Containers[0].Image = "ballista:0.6.0"
+		outdated, updated := partitionExecutorsByHash(pods, executorTemplateHashes(cluster))
+		Expect(outdated).To(HaveLen(3))
+		Expect(updated).To(HaveLen(2))
+
+		cluster.Spec.ExecutorPools = nil
+		outdated, updated = partitionExecutorsByHash(pods, executorTemplateHashes(cluster))
+		Expect(outdated).To(BeEmpty())
+		Expect(updated).To(HaveLen(2))
+	})
+})
@@ -0,0 +1,245 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	k8sapiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+// preemptedPodReasons are the reasons a pod fails with when it is evicted or its node is reclaimed.
+var preemptedPodReasons = map[string]bool{
+	"Evicted":      true,
+	"Preempting":   true,
+	"Shutdown":     true,
+	"NodeShutdown": true,
+	"Terminated":   true,
+	"NodeLost":     true,
+}
+
+// nodePreemptionTaints are the taints cloud providers and their termination handlers put on a node about to
+// be reclaimed.
+var nodePreemptionTaints = map[string]bool{
+	"cloud.google.com/impending-node-termination":        true,
+	"aws-node-termination-handler/spot-itn":              true,
+	"aws-node-termination-handler/scheduled-maintenance": true,
+	"ToBeDeletedByClusterAutoscaler":                     true,
+}
+
+// reconcilePreemptedExecutors reacts to the preemption of executors: those evicted or whose node was reclaimed
+// are deleted, and those on a node about to be reclaimed are decommissioned, so that reconcileExecutors
+// replaces them right away. Every preemption is counted in the status of the cluster. It returns the executors
+// left, and how long to wait before a fallback pool may give its executors back.
+func (r *BallistaClusterReconciler) reconcilePreemptedExecutors(ctx context.Context, cluster *v1.BallistaCluster, executors []k8sapiv1.Pod, now time.Time) ([]k8sapiv1.Pod, time.Duration, error) {
+	log := log.FromContext(ctx)
+
+	var left, preempted []k8sapiv1.Pod
+	nodes := make(map[string]bool)
+	for i := range executors {
+		executor := &executors[i]
+		if executor.DeletionTimestamp != nil || isExecutorDecommissioning(executor) {
+			// a decommissioning executor was already replaced, whatever becomes of it
+			left = append(left, *executor)
+			continue
+		}
+		if isExecutorPreempted(executor) {
+			log.Info("removing preempted executor", "executor", executor.Name, "reason", executor.Status.Reason)
+			if err := r.deleteIfNotDeleting(ctx, executor); err != nil {
+				return nil, 0, err
+			}
+			delete(cluster.Status.ExecutorState, executor.Name)
+			r.recordPreemption(cluster, executor, now)
+			r.Recorder.Eventf(cluster, k8sapiv1.EventTypeWarning, eventExecutorPreempted, "Replacing executor pod %s: %s",
+				executor.Name, executorFailureMessage(executor))
+			continue
+		}
+		if isPodActive(executor) && executor.Spec.NodeName != "" {
+			reclaimed, ok := nodes[executor.Spec.NodeName]
+			if !ok {
+				var err error
+				if reclaimed, err = r.isNodePreempted(ctx, executor.Spec.NodeName); err != nil {
+					return nil, 0, err
+				}
+				nodes[executor.Spec.NodeName] = reclaimed
+			}
+			if reclaimed {
+				preempted = append(preempted, *executor)
+				continue
+			}
+		}
+		left = append(left, *executor)
+	}
+
+	if len(preempted) > 0 {
+		if err := r.decommissionExecutors(ctx, cluster, preempted, r.registeredExecutors(ctx, cluster),
+			"on the preemption of its node", now); err != nil {
+			return nil, 0, err
+		}
+		for i := range preempted {
+			r.recordPreemption(cluster, &preempted[i], now)
+			r.Recorder.Eventf(cluster, k8sapiv1.EventTypeWarning, eventExecutorPreempted, "Replacing executor pod %s: node %s is being preempted",
+				preempted[i].Name, preempted[i].Spec.NodeName)
+		}
+		left = append(left, preempted...)
+	}
+	return left, pruneRecentPreemptions(cluster, now), nil
+}
+
+// nodePreemptionPredicate lets through the events of nodes starting to be preempted, or gone.
+var nodePreemptionPredicate = predicate.Funcs{
+	CreateFunc:  func(event.CreateEvent) bool { return false },
+	DeleteFunc:  func(event.DeleteEvent) bool { return true },
+	GenericFunc: func(event.GenericEvent) bool { return false },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldNode, okOld := e.ObjectOld.(*k8sapiv1.Node)
+		newNode, okNew := e.ObjectNew.(*k8sapiv1.Node)
+		return okOld && okNew && !hasPreemptionTaint(oldNode) && hasPreemptionTaint(newNode)
+	},
+}
+
+// clustersOnNode maps a node to the clusters running executors on it.
+func (r *BallistaClusterReconciler) clustersOnNode(node client.Object) []reconcile.Request {
+	var pods k8sapiv1.PodList
+	if err := r.List(context.Background(), &pods, client.MatchingFields{podNodeKey: node.GetName()},
+		client.MatchingLabels{podBallistaRoleKey: executorRole}); err != nil {
+		log.Log.Error(err, "unable to list the executor pods of a node", "node", node.GetName())
+		return nil
+	}
+	clusters := make(map[types.NamespacedName]bool)
+	var requests []reconcile.Request
+	for i := range pods.Items {
+		owner := metav1.GetControllerOf(&pods.Items[i])
+		if owner == nil || owner.APIVersion != apiGVStr || owner.Kind != "BallistaCluster" {
+			continue
+		}
+		name := types.NamespacedName{Name: owner.Name, Namespace: pods.Items[i].Namespace}
+		if !clusters[name] {
+			clusters[name] = true
+			requests = append(requests, reconcile.Request{NamespacedName: name})
+		}
+	}
+	return requests
+}
+
+// isNodePreempted tells whether the node is about to be reclaimed, or already gone.
+func (r *BallistaClusterReconciler) isNodePreempted(ctx context.Context, name string) (bool, error) {
+	node := &k8sapiv1.Node{}
+	if err := r.Get(ctx, types.NamespacedName{Name: name}, node); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		log.FromContext(ctx).Error(err, "unable to get the node of an executor", "node", name)
+		return false, err
+	}
+	return hasPreemptionTaint(node), nil
+}
+
+// hasPreemptionTaint tells whether the node is tainted as about to be reclaimed.
+func hasPreemptionTaint(node *k8sapiv1.Node) bool {
+	for _, taint := range node.Spec.Taints {
+		if nodePreemptionTaints[taint.Key] {
+			return true
+		}
+	}
+	return false
+}
+
+// recordPreemption counts the preemption of the executor, and remembers it while it counts towards the
+// fallback of its pool, recording an event when the pool falls back.
+func (r *BallistaClusterReconciler) recordPreemption(cluster *v1.BallistaCluster, executor *k8sapiv1.Pod, now time.Time) {
+	cluster.Status.Preemptions++
+
+	name := executor.Labels[podExecutorPoolKey]
+	pool := executorPoolSpec(cluster, name)
+	if pool == nil || pool.Fallback == nil {
+		return
+	}
+	wasActive := isFallbackActive(cluster, pool)
+	cluster.Status.RecentPreemptions = append(cluster.Status.RecentPreemptions, v1.ExecutorPreemption{
+		Pool:     name,
+		Executor: executor.Name,
+		Time:     metav1.NewTime(now),
+	})
+	if !wasActive && isFallbackActive(cluster, pool) {
+		r.Recorder.Eventf(cluster, k8sapiv1.EventTypeWarning, eventExecutorPoolFallback,
+			"Moving the executors of pool %s to pool %s after %d preemptions within %s", name, pool.Fallback.PoolName,
+			pool.Fallback.Preemptions, fallbackWindow(pool))
+	}
+}
+
+// pruneRecentPreemptions forgets the preemptions out of the fallback window of their pool, and returns how
+// long until the next one is, zero when none are left.
+func pruneRecentPreemptions(cluster *v1.BallistaCluster, now time.Time) time.Duration {
+	var recent []v1.ExecutorPreemption
+	var requeue time.Duration
+	for _, preemption := range cluster.Status.RecentPreemptions {
+		pool := executorPoolSpec(cluster, preemption.Pool)
+		if pool == nil || pool.Fallback == nil {
+			continue
+		}
+		remaining := preemption.Time.Add(fallbackWindow(pool)).Sub(now)
+		if remaining <= 0 {
+			continue
+		}
+		recent = append(recent, preemption)
+		requeue = minRequeueInterval(requeue, remaining)
+	}
+	cluster.Status.RecentPreemptions = recent
+	return requeue
+}
+
+// isFallbackActive tells whether the executors of the pool are moved to its fallback pool: enough of them
+// were preempted within the fallback window, that is are still among the recent preemptions once
+// pruneRecentPreemptions forgot the older ones.
+func isFallbackActive(cluster *v1.BallistaCluster, pool *v1.ExecutorPool) bool {
+	if pool.Fallback == nil {
+		return false
+	}
+	count := int32(0)
+	for _, preemption := range cluster.Status.RecentPreemptions {
+		if preemption.Pool == pool.Name {
+			count++
+		}
+	}
+	return count >= pool.Fallback.Preemptions
+}
+
+// isExecutorPreempted tells whether an executor pod failed because it was evicted or its node was reclaimed.
+func isExecutorPreempted(pod *k8sapiv1.Pod) bool {
+	return pod.Status.Phase == k8sapiv1.PodFailed && preemptedPodReasons[pod.Status.Reason]
+}
+
+// fallbackWindow returns the time window the preemptions of the executors of the pool are counted over.
+func fallbackWindow(pool *v1.ExecutorPool) time.Duration {
+	seconds := v1.DefaultPreemptionWindowSeconds
+	if pool.Fallback.WindowSeconds != nil {
+		seconds = *pool.Fallback.WindowSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	k8sapiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+var _ = Describe("BallistaCluster executor preemption", func() {
+	var ctx context.Context
+	var cluster *v1.BallistaCluster
+	var recorder *record.FakeRecorder
+	var r *BallistaClusterReconciler
+
+	spotNode := &k8sapiv1.Node{ObjectMeta: metav1.ObjectMeta{Name: "spot-node"}}
+	healthyNode := &k8sapiv1.Node{ObjectMeta: metav1.ObjectMeta{Name: "healthy-node"}}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		cluster = &v1.BallistaCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default", UID: "cluster-uid"}}
+		cluster.Spec.Executor.Instances = int32Ptr(0)
+		cluster.Spec.ExecutorPools = []v1.ExecutorPool{
+			{
+				Name:      "spot",
+				PodSpec:   k8sapiv1.PodSpec{Containers: []k8sapiv1.Container{{Name: v1.ExecutorContainerName, Image: "ballista:0.5.0"}}},
+				Instances: int32Ptr(2),
+				Fallback:  &v1.ExecutorPoolFallback{PoolName: "on-demand", Preemptions: 2, WindowSeconds: int32Ptr(600)},
+			},
+			{
+				Name:      "on-demand",
+				PodSpec:   k8sapiv1.PodSpec{Containers: []k8sapiv1.Container{{Name: v1.ExecutorContainerName, Image: "ballista:0.5.0"}}},
+				Instances: int32Ptr(1),
+			},
+		}
+		cluster.Status.SchedulerState = v1.SchedulerRunningState
+
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(v1.AddToScheme(scheme)).To(Succeed())
+		recorder = record.NewFakeRecorder(20)
+		node := spotNode.DeepCopy()
+		node.Spec.Taints = []k8sapiv1.Taint{{Key: "cloud.google.com/impending-node-termination", Effect: k8sapiv1.TaintEffectNoSchedule}}
+		r = &BallistaClusterReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, node, healthyNode.DeepCopy()).Build(),
+			Scheme:   scheme,
+			Recorder: recorder,
+			Clock:    clock.NewFakeClock(time.Now()),
+		}
+
+		_, err := r.reconcileExecutors(ctx, cluster, r.Clock.Now())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Events).To(Receive(ContainSubstring(eventExecutorsCreated)))
+	})
+
+	executors := func() map[string]k8sapiv1.Pod {
+		pods, err := r.listClusterPods(ctx, cluster, executorRole)
+		Expect(err).NotTo(HaveOccurred())
+		byName := make(map[string]k8sapiv1.Pod, len(pods))
+		for _, pod := range pods {
+			byName[pod.Name] = pod
+		}
+		return byName
+	}
+
+	// place runs the executor on the node, in the given phase for the given reason.
+	place := func(name string, node string, phase k8sapiv1.PodPhase, reason string) {
+		pod := executors()[name]
+		pod.Spec.NodeName = node
+		Expect(r.Update(ctx, &pod)).To(Succeed())
+		pod.Status.Phase = phase
+		pod.Status.Reason = reason
+		Expect(r.Status().Update(ctx, &pod)).To(Succeed())
+	}
+
+	It("replaces an evicted executor", func() {
+		place("cluster-executor-spot-0", "healthy-node", k8sapiv1.PodFailed, "Evicted")
+
+		_, err := r.reconcileExecutors(ctx, cluster, r.Clock.Now())
+		Expect(err).NotTo(HaveOccurred())
+		pods := executors()
+		Expect(pods).NotTo(HaveKey("cluster-executor-spot-0"))
+		Expect(pods).To(HaveKey("cluster-executor-spot-2"))
+		Expect(cluster.Status.Preemptions).To(Equal(int32(1)))
+		Expect(cluster.Status.RecentPreemptions).To(HaveLen(1))
+		Expect(recorder.Events).To(Receive(ContainSubstring(eventExecutorPreempted)))
+	})
+
+	It("leaves an executor that failed on its own", func() {
+		place("cluster-executor-spot-0", "healthy-node", k8sapiv1.PodFailed, "")
+
+		_, err := r.reconcileExecutors(ctx, cluster, r.Clock.Now())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(executors()).To(HaveKey("cluster-executor-spot-0"))
+		Expect(cluster.Status.Preemptions).To(BeZero())
+	})
+
+	It("replaces the executors of a node being preempted", func() {
+		place("cluster-executor-spot-0", "spot-node", k8sapiv1.PodRunning, "")
+		place("cluster-executor-spot-1", "healthy-node", k8sapiv1.PodRunning, "")
+
+		_, err := r.reconcileExecutors(ctx, cluster, r.Clock.Now())
+		Expect(err).NotTo(HaveOccurred())
+		pods := executors()
+		preempted := pods["cluster-executor-spot-0"]
+		Expect(isExecutorDecommissioning(&preempted)).To(BeTrue())
+		healthy := pods["cluster-executor-spot-1"]
+		Expect(isExecutorDecommissioning(&healthy)).To(BeFalse())
+		Expect(pods).To(HaveKey("cluster-executor-spot-2"))
+		Expect(cluster.Status.Preemptions).To(Equal(int32(1)))
+
+		// the decommissioning executor is not counted again
+		_, err = r.reconcileExecutors(ctx, cluster, r.Clock.Now())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cluster.Status.Preemptions).To(Equal(int32(1)))
+	})
+
+	It("falls back to another pool after too many preemptions", func() {
+		place("cluster-executor-spot-0", "healthy-node", k8sapiv1.PodFailed, "Evicted")
+		place("cluster-executor-spot-1", "gone-node", k8sapiv1.PodRunning, "")
+
+		_, err := r.reconcileExecutors(ctx, cluster, r.Clock.Now())
+		Expect(err).NotTo(HaveOccurred())
+		var events []string
+		for len(recorder.Events) > 0 {
+			events = append(events, <-recorder.Events)
+		}
+		Expect(events).To(ContainElement(ContainSubstring(eventExecutorPoolFallback)))
+		pools := executorPools(cluster)
+		Expect(pools[1]).To(Equal(executorPool{name: "spot", fallbackActive: true}))
+		Expect(pools[2]).To(Equal(executorPool{name: "on-demand", instances: 3}))
+
+		pods := executors()
+		Expect(pods).To(HaveKey("cluster-executor-on-demand-2"))
+		Expect(pods).NotTo(HaveKey("cluster-executor-spot-2"))
+	})
+
+	It("moves the executors back once the window passes", func() {
+		fakeClock := r.Clock.(*clock.FakeClock)
+		cluster.Status.RecentPreemptions = []v1.ExecutorPreemption{
+			{Pool: "spot", Executor: "cluster-executor-spot-5", Time: metav1.NewTime(fakeClock.Now())},
+		}
+		fakeClock.Step(5 * time.Minute)
+		cluster.Status.RecentPreemptions = append(cluster.Status.RecentPreemptions,
+			v1.ExecutorPreemption{Pool: "spot", Executor: "cluster-executor-spot-6", Time: metav1.NewTime(fakeClock.Now())})
+		Expect(isFallbackActive(cluster, &cluster.Spec.ExecutorPools[0])).To(BeTrue())
+
+		fakeClock.Step(6 * time.Minute)
+		requeue := pruneRecentPreemptions(cluster, fakeClock.Now())
+		Expect(cluster.Status.RecentPreemptions).To(HaveLen(1))
+		Expect(requeue).To(Equal(4 * time.Minute))
+		Expect(isFallbackActive(cluster, &cluster.Spec.ExecutorPools[0])).To(BeFalse())
+	})
+
+	It("watches the nodes starting to be preempted", func() {
+		tainted := spotNode.DeepCopy()
+		tainted.Spec.Taints = []k8sapiv1.Taint{{Key: "aws-node-termination-handler/spot-itn"}}
+		Expect(nodePreemptionPredicate.Update(event.UpdateEvent{ObjectOld: spotNode, ObjectNew: tainted})).To(BeTrue())
+		Expect(nodePreemptionPredicate.Update(event.UpdateEvent{ObjectOld: tainted, ObjectNew: tainted})).To(BeFalse())
+		Expect(nodePreemptionPredicate.Delete(event.DeleteEvent{Object: spotNode})).To(BeTrue())
+	})
+})
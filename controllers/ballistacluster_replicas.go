@@ -0,0 +1,148 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	k8sapiv1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+// schedulerStateRank orders the scheduler states from the least to the most telling of a scheduler that
+// serves.
+var schedulerStateRank = map[v1.SchedulerState]int{
+	v1.SchedulerUnknownState:   0,
+	v1.SchedulerCompletedState: 1,
+	v1.SchedulerFailedState:    2,
+	v1.SchedulerPendingState:   3,
+	v1.SchedulerRunningState:   4,
+}
+
+// schedulerReplicaStatus returns the observed state of a scheduler pod.
+func schedulerReplicaStatus(pod *k8sapiv1.Pod) v1.SchedulerReplicaStatus {
+	replica := v1.SchedulerReplicaStatus{Name: pod.Name, State: podPhaseToSchedulerState(pod.Status.Phase)}
+	if pod.DeletionTimestamp != nil {
+		// the replica is on its way out and gets replaced once it is gone
+		replica.State = v1.SchedulerPendingState
+	}
+	switch replica.State {
+	case v1.SchedulerRunningState:
+		replica.Ready = isPodReady(pod)
+	case v1.SchedulerFailedState, v1.SchedulerCompletedState:
+		replica.Message = fmt.Sprintf("scheduler pod %s is %s: %s", pod.Name, replica.State, podTerminationMessage(pod))
+	}
+	return replica
+}
+
+// aggregateSchedulerState returns the state of the scheduler as a whole: the state of its replica that tells
+// most of a scheduler that serves, along with the message of that replica.
+func aggregateSchedulerState(replicas []v1.SchedulerReplicaStatus) (v1.SchedulerState, string) {
+	state, message := v1.SchedulerUnknownState, ""
+	for _, replica := range replicas {
+		if schedulerStateRank[replica.State] > schedulerStateRank[state] {
+			state, message = replica.State, replica.Message
+		}
+	}
+	return state, message
+}
+
+// spreadSchedulerReplicas makes the replicas of a highly available scheduler serve only once ready, and
+// prefer nodes that run no other replica, unless the scheduler PodSpec decides otherwise.
+func spreadSchedulerReplicas(cluster *v1.BallistaCluster, template *k8sapiv1.PodTemplateSpec) {
+	spec := &template.Spec
+	if len(spec.Containers) > 0 && spec.Containers[0].ReadinessProbe == nil {
+		spec.Containers[0].ReadinessProbe = &k8sapiv1.Probe{
+			Handler: k8sapiv1.Handler{
+				TCPSocket: &k8sapiv1.TCPSocketAction{Port: intstr.FromInt(int(schedulerPort(cluster)))},
+			},
+			PeriodSeconds: 10,
+		}
+	}
+	if spec.Affinity == nil {
+		spec.Affinity = &k8sapiv1.Affinity{}
+	}
+	if spec.Affinity.PodAntiAffinity == nil {
+		spec.Affinity.PodAntiAffinity = &k8sapiv1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []k8sapiv1.WeightedPodAffinityTerm{{
+				Weight: 100,
+				PodAffinityTerm: k8sapiv1.PodAffinityTerm{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: roleSelector(cluster, schedulerRole)},
+					TopologyKey:   k8sapiv1.LabelHostname,
+				},
+			}},
+		}
+	}
+}
+
+// reconcileSchedulerDisruptionBudget keeps voluntary disruptions from taking down more than one replica of
+// a highly available scheduler at a time. The budget is removed once the scheduler has a single replica.
+func (r *BallistaClusterReconciler) reconcileSchedulerDisruptionBudget(ctx context.Context, cluster *v1.BallistaCluster) error {
+	log := log.FromContext(ctx)
+
+	budget := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      schedulerDisruptionBudgetName(cluster),
+			Namespace: cluster.Namespace,
+		},
+	}
+	if schedulerReplicas(cluster) <= 1 {
+		err := r.Get(ctx, types.NamespacedName{Name: budget.Name, Namespace: budget.Namespace}, budget)
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			log.Error(err, "unable to fetch scheduler disruption budget", "budget", budget.Name)
+			return err
+		}
+		if !metav1.IsControlledBy(budget, cluster) {
+			return nil
+		}
+		return r.deleteIfNotDeleting(ctx, budget)
+	}
+
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, budget, func() error {
+		budget.Labels = clusterLabels(cluster, schedulerRole)
+		maxUnavailable := intstr.FromInt(1)
+		budget.Spec.MaxUnavailable = &maxUnavailable
+		budget.Spec.Selector = &metav1.LabelSelector{MatchLabels: roleSelector(cluster, schedulerRole)}
+		return ctrl.SetControllerReference(cluster, budget, r.Scheme)
+	})
+	if err != nil {
+		log.Error(err, "unable to reconcile scheduler disruption budget for Ballista Cluster", "budget", budget.Name)
+		return err
+	}
+	if result != controllerutil.OperationResultNone {
+		log.Info("reconciled scheduler disruption budget", "budget", budget.Name, "result", result)
+	}
+	return nil
+}
+
+// schedulerDisruptionBudgetName returns the name of the PodDisruptionBudget of the scheduler of the cluster.
+func schedulerDisruptionBudgetName(cluster *v1.BallistaCluster) string {
+	return fmt.Sprintf("%s-%s", cluster.Name, schedulerRole)
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	k8sapiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+var _ = Describe("BallistaCluster scheduler replicas", func() {
+	var cluster *v1.BallistaCluster
+
+	BeforeEach(func() {
+		cluster = &v1.BallistaCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"}}
+		cluster.Spec.Scheduler.Containers = []k8sapiv1.Container{{Name: "scheduler", Image: "ballista:0.5.0"}}
+	})
+
+	It("names the first replica after the cluster", func() {
+		Expect(schedulerPodName(cluster, 0)).To(Equal("cluster-scheduler"))
+		Expect(schedulerPodName(cluster, 2)).To(Equal("cluster-scheduler-2"))
+	})
+
+	It("leaves a single scheduler where it is scheduled", func() {
+		template := schedulerPodTemplate(cluster)
+		Expect(template.Spec.Affinity).To(BeNil())
+		Expect(template.Spec.Containers[0].ReadinessProbe).To(BeNil())
+	})
+
+	It("spreads the replicas and gates them on readiness", func() {
+		cluster.Spec.Scheduler.Replicas = int32Ptr(3)
+		template := schedulerPodTemplate(cluster)
+
+		terms := template.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+		Expect(terms).To(HaveLen(1))
+		Expect(terms[0].PodAffinityTerm.TopologyKey).To(Equal(k8sapiv1.LabelHostname))
+		Expect(terms[0].PodAffinityTerm.LabelSelector.MatchLabels).To(Equal(roleSelector(cluster, schedulerRole)))
+		probe := template.Spec.Containers[0].ReadinessProbe
+		Expect(probe.TCPSocket.Port.IntValue()).To(Equal(int(v1.DefaultSchedulerPort)))
+	})
+
+	It("keeps the readiness probe of the scheduler PodSpec", func() {
+		cluster.Spec.Scheduler.Replicas = int32Ptr(2)
+		probe := &k8sapiv1.Probe{Handler: k8sapiv1.Handler{Exec: &k8sapiv1.ExecAction{Command: []string{"true"}}}}
+		cluster.Spec.Scheduler.Containers[0].ReadinessProbe = probe
+		Expect(schedulerPodTemplate(cluster).Spec.Containers[0].ReadinessProbe).To(Equal(probe))
+	})
+
+	It("reports the health of a replica", func() {
+		pod := &k8sapiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-scheduler-1"},
+			Status: k8sapiv1.PodStatus{
+				Phase:      k8sapiv1.PodRunning,
+				Conditions: []k8sapiv1.PodCondition{{Type: k8sapiv1.PodReady, Status: k8sapiv1.ConditionTrue}},
+			},
+		}
+		Expect(schedulerReplicaStatus(pod)).To(Equal(v1.SchedulerReplicaStatus{
+			Name:  "cluster-scheduler-1",
+			State: v1.SchedulerRunningState,
+			Ready: true,
+		}))
+
+		pod.Status.Phase = k8sapiv1.PodFailed
+		pod.Status.Message = "evicted"
+		replica := schedulerReplicaStatus(pod)
+		Expect(replica.Ready).To(BeFalse())
+		Expect(replica.Message).To(Equal("scheduler pod cluster-scheduler-1 is FAILED: evicted"))
+	})
+
+	DescribeTable("aggregateSchedulerState",
+		func(states []v1.SchedulerState, expected v1.SchedulerState) {
+			var replicas []v1.SchedulerReplicaStatus
+			for _, state := range states {
+				replicas = append(replicas, v1.SchedulerReplicaStatus{State: state})
+			}
+			state, _ := aggregateSchedulerState(replicas)
+			Expect(state).To(Equal(expected))
+		},
+		Entry("no replica", nil, v1.SchedulerUnknownState),
+		Entry("single replica", []v1.SchedulerState{v1.SchedulerPendingState}, v1.SchedulerPendingState),
+		Entry("one replica running", []v1.SchedulerState{v1.SchedulerFailedState, v1.SchedulerRunningState, v1.SchedulerPendingState},
+			v1.SchedulerRunningState),
+		Entry("replacement pending", []v1.SchedulerState{v1.SchedulerFailedState, v1.SchedulerPendingState}, v1.SchedulerPendingState),
+		Entry("every replica failed", []v1.SchedulerState{v1.SchedulerCompletedState, v1.SchedulerFailedState}, v1.SchedulerFailedState),
+	)
+})
@@ -0,0 +1,273 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	k8sapiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+// clusterStateHandler moves a cluster in a given state towards its next state, now being the time of the
+// reconcile. It may change the status of the cluster, which the caller persists.
+type clusterStateHandler func(r *BallistaClusterReconciler, ctx context.Context, cluster *v1.BallistaCluster, now time.Time) (ctrl.Result, error)
+
+// clusterStateHandlers maps every cluster state to the handler driving it. Clusters in a state missing from
+// the table are handled like UnknownState.
+var clusterStateHandlers = map[v1.ClusterStateType]clusterStateHandler{
+	v1.NewState:              (*BallistaClusterReconciler).startBallistaCluster,
+	v1.Pending:               (*BallistaClusterReconciler).observeBallistaCluster,
+	v1.RunningState:          (*BallistaClusterReconciler).observeBallistaCluster,
+	v1.RestartWhenReadyState: (*BallistaClusterReconciler).observeBallistaCluster,
+	v1.Restarting:            (*BallistaClusterReconciler).restartBallistaCluster,
+	v1.TerminateWhenReady:    (*BallistaClusterReconciler).observeBallistaCluster,
+	v1.Terminating:           (*BallistaClusterReconciler).terminateBallistaCluster,
+	v1.Terminated:            (*BallistaClusterReconciler).reconcileTerminatedCluster,
+	v1.UnknownState:          (*BallistaClusterReconciler).observeBallistaCluster,
+}
+
+const (
+	// unknownStateRequeueInterval is how often a cluster whose pods cannot be observed is checked again.
+	unknownStateRequeueInterval = 30 * time.Second
+	// whenReadyRequeueInterval is how often a cluster waiting to be restarted or terminated is checked again.
+	whenReadyRequeueInterval = 30 * time.Second
+)
+
+// nextClusterState returns the state an observed cluster moves to, given its current state, the state of its
+// scheduler and executors, and whether it is idle. A cluster waiting to restart or terminate proceeds once it
+// is idle, or right away when its scheduler is gone.
+func nextClusterState(current v1.ClusterStateType, scheduler v1.SchedulerState, runningExecutors, desiredExecutors int, idle bool) v1.ClusterStateType {
+	observed := schedulerStateToClusterState(scheduler, runningExecutors, desiredExecutors)
+	switch current {
+	case v1.RestartWhenReadyState:
+		if idle || observed == v1.Restarting {
+			return v1.Restarting
+		}
+		return current
+	case v1.TerminateWhenReady:
+		if idle || observed == v1.Restarting {
+			return v1.Terminating
+		}
+		return current
+	default:
+		return observed
+	}
+}
+
+// acceptRequestedOperation moves the cluster to the state carrying out the operation requested with
+// RequestedOperationAnnotation, or resumes it, and returns whether an operation was requested. Requests the
+// cluster cannot honor in its current state, e.g. while it is already restarting, are dropped.
+func acceptRequestedOperation(cluster *v1.BallistaCluster, now metav1.Time) bool {
+	operation, ok := cluster.Annotations[v1.RequestedOperationAnnotation]
+	if !ok {
+		return false
+	}
+	if operation == v1.ResumeOperation {
+		resumeCluster(cluster, v1.ResumeRequestedReason, "resumed on request")
+		return true
+	}
+
+	status := &cluster.Status
+	switch status.ClusterState.State {
+	case v1.Pending, v1.RunningState, v1.UnknownState, v1.RestartWhenReadyState, v1.TerminateWhenReady:
+		switch operation {
+		case v1.RestartOperation:
+			status.ClusterState.State = v1.RestartWhenReadyState
+		case v1.TerminateOperation:
+			status.ClusterState.State = v1.TerminateWhenReady
+		default:
+			return true
+		}
+		if status.WhenReadySince == nil {
+			status.WhenReadySince = &now
+		}
+	case v1.Terminated:
+		if operation == v1.RestartOperation {
+			status.ClusterState.State = v1.Restarting
+		}
+	}
+	return true
+}
+
+// whenReadyTimedOut tells whether a cluster waiting to restart or terminate has waited long enough for its
+// jobs to finish.
+func whenReadyTimedOut(cluster *v1.BallistaCluster, now time.Time) bool {
+	if cluster.Status.WhenReadySince == nil {
+		return false
+	}
+	timeout := time.Duration(v1.DefaultWhenReadyTimeoutSeconds) * time.Second
+	if cluster.Spec.WhenReadyTimeoutSeconds != nil {
+		timeout = time.Duration(*cluster.Spec.WhenReadyTimeoutSeconds) * time.Second
+	}
+	return !cluster.Status.WhenReadySince.Add(timeout).After(now)
+}
+
+// schedulerStateToClusterState returns the state a cluster is in given the state of its scheduler and
+// executors. A cluster whose scheduler is gone is restarted, since executors cannot outlive it.
+func schedulerStateToClusterState(scheduler v1.SchedulerState, runningExecutors, desiredExecutors int) v1.ClusterStateType {
+	switch scheduler {
+	case v1.SchedulerRunningState:
+		if runningExecutors >= desiredExecutors {
+			return v1.RunningState
+		}
+		return v1.Pending
+	case v1.SchedulerPendingState:
+		return v1.Pending
+	case v1.SchedulerFailedState, v1.SchedulerCompletedState:
+		return v1.Restarting
+	default:
+		return v1.UnknownState
+	}
+}
+
+// clusterStateRequeueInterval returns how long to wait before observing a cluster in the given state again.
+// Zero means the cluster is only observed again when it or one of its pods changes.
+func clusterStateRequeueInterval(state v1.ClusterStateType) time.Duration {
+	switch state {
+	case v1.UnknownState:
+		return unknownStateRequeueInterval
+	case v1.RestartWhenReadyState, v1.TerminateWhenReady:
+		return whenReadyRequeueInterval
+	case v1.Restarting, v1.Terminating:
+		return terminationPollInterval
+	default:
+		return 0
+	}
+}
+
+// minRequeueInterval returns the shorter of two requeue intervals, where zero means no requeue.
+func minRequeueInterval(a, b time.Duration) time.Duration {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// podPhaseToSchedulerState maps the phase of the scheduler pod to a scheduler state.
+func podPhaseToSchedulerState(phase k8sapiv1.PodPhase) v1.SchedulerState {
+	switch phase {
+	case k8sapiv1.PodPending:
+		return v1.SchedulerPendingState
+	case k8sapiv1.PodRunning:
+		return v1.SchedulerRunningState
+	case k8sapiv1.PodSucceeded:
+		return v1.SchedulerCompletedState
+	case k8sapiv1.PodFailed:
+		return v1.SchedulerFailedState
+	default:
+		return v1.SchedulerUnknownState
+	}
+}
+
+// podPhaseToExecutorState maps the phase of an executor pod to an executor state.
+func podPhaseToExecutorState(phase k8sapiv1.PodPhase) v1.ExecutorState {
+	switch phase {
+	case k8sapiv1.PodPending:
+		return v1.ExecutorPendingState
+	case k8sapiv1.PodRunning:
+		return v1.ExecutorRunningState
+	case k8sapiv1.PodSucceeded:
+		return v1.ExecutorCompletedState
+	case k8sapiv1.PodFailed:
+		return v1.ExecutorFailedState
+	default:
+		return v1.ExecutorUnknownState
+	}
+}
+
+// podToExecutorState refines the phase of an executor pod with the state of its containers: a pod whose
+// containers cannot start has failed, and a running pod only counts as running once all containers are ready.
+func podToExecutorState(pod *k8sapiv1.Pod) v1.ExecutorState {
+	state := podPhaseToExecutorState(pod.Status.Phase)
+	if state != v1.ExecutorPendingState && state != v1.ExecutorRunningState {
+		return state
+	}
+
+	ready := len(pod.Status.ContainerStatuses) > 0
+	for _, status := range pod.Status.ContainerStatuses {
+		if waiting := status.State.Waiting; waiting != nil && failedContainerReasons[waiting.Reason] {
+			return v1.ExecutorFailedState
+		}
+		ready = ready && status.Ready
+	}
+	if state == v1.ExecutorRunningState && !ready {
+		return v1.ExecutorPendingState
+	}
+	return state
+}
+
+// failedContainerReasons are the reasons a waiting container gives when it will not start without intervention.
+var failedContainerReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
+
+// resetSchedulerStatus forgets every scheduler replica of the cluster.
+func resetSchedulerStatus(cluster *v1.BallistaCluster) {
+	cluster.Status.SchedulerState = ""
+	cluster.Status.Schedulers = nil
+	cluster.Status.ReadySchedulers = 0
+}
+
+// resetExecutorStatus forgets every executor of the cluster.
+func resetExecutorStatus(cluster *v1.BallistaCluster) {
+	cluster.Status.ExecutorState = nil
+	cluster.Status.ReadyExecutors = 0
+	cluster.Status.PendingExecutors = 0
+	cluster.Status.FailedExecutors = 0
+	cluster.Status.Executors = 0
+	cluster.Status.ExecutorPools = nil
+}
+
+// executorFailureMessage explains why an executor pod failed or cannot start.
+func executorFailureMessage(pod *k8sapiv1.Pod) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if waiting := status.State.Waiting; waiting != nil && failedContainerReasons[waiting.Reason] {
+			if waiting.Message != "" {
+				return fmt.Sprintf("%s: %s", waiting.Reason, waiting.Message)
+			}
+			return waiting.Reason
+		}
+	}
+	return podTerminationMessage(pod)
+}
+
+// podTerminationMessage explains why a pod terminated, as far as Kubernetes knows.
+func podTerminationMessage(pod *k8sapiv1.Pod) string {
+	if pod.Status.Message != "" {
+		return pod.Status.Message
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if terminated := status.State.Terminated; terminated != nil {
+			if terminated.Message != "" {
+				return terminated.Message
+			}
+			return terminated.Reason
+		}
+	}
+	return pod.Status.Reason
+}
@@ -0,0 +1,329 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	k8sapiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+var _ = Describe("BallistaCluster state machine", func() {
+
+	It("has a handler for every cluster state", func() {
+		for _, state := range []v1.ClusterStateType{
+			v1.NewState, v1.Pending, v1.RunningState, v1.RestartWhenReadyState, v1.Restarting,
+			v1.TerminateWhenReady, v1.Terminating, v1.Terminated, v1.UnknownState,
+		} {
+			Expect(clusterStateHandlers).To(HaveKey(state))
+		}
+	})
+
+	DescribeTable("nextClusterState",
+		func(current v1.ClusterStateType, scheduler v1.SchedulerState, running, desired int, idle bool, expected v1.ClusterStateType) {
+			Expect(nextClusterState(current, scheduler, running, desired, idle)).To(Equal(expected))
+		},
+		Entry("pending while the scheduler is pending", v1.Pending, v1.SchedulerPendingState, 0, 2, false, v1.Pending),
+		Entry("pending while executors are missing", v1.Pending, v1.SchedulerRunningState, 1, 2, false, v1.Pending),
+		Entry("pending to running", v1.Pending, v1.SchedulerRunningState, 2, 2, false, v1.RunningState),
+		Entry("pending to restarting on scheduler failure", v1.Pending, v1.SchedulerFailedState, 0, 2, false, v1.Restarting),
+		Entry("pending to unknown", v1.Pending, v1.SchedulerUnknownState, 0, 2, false, v1.UnknownState),
+		Entry("running stays running", v1.RunningState, v1.SchedulerRunningState, 3, 2, false, v1.RunningState),
+		Entry("running to pending on executor loss", v1.RunningState, v1.SchedulerRunningState, 1, 2, false, v1.Pending),
+		Entry("running to restarting on scheduler failure", v1.RunningState, v1.SchedulerFailedState, 2, 2, false, v1.Restarting),
+		Entry("running to restarting on scheduler completion", v1.RunningState, v1.SchedulerCompletedState, 2, 2, false, v1.Restarting),
+		Entry("running to unknown", v1.RunningState, v1.SchedulerUnknownState, 2, 2, false, v1.UnknownState),
+		Entry("unknown to running", v1.UnknownState, v1.SchedulerRunningState, 2, 2, false, v1.RunningState),
+		Entry("unknown to pending", v1.UnknownState, v1.SchedulerPendingState, 0, 2, false, v1.Pending),
+		Entry("unknown to restarting", v1.UnknownState, v1.SchedulerFailedState, 0, 2, false, v1.Restarting),
+		Entry("unknown stays unknown", v1.UnknownState, v1.SchedulerUnknownState, 0, 2, false, v1.UnknownState),
+		Entry("restart when ready waits for running jobs", v1.RestartWhenReadyState, v1.SchedulerRunningState, 2, 2, false, v1.RestartWhenReadyState),
+		Entry("restart when ready waits on unknown scheduler", v1.RestartWhenReadyState, v1.SchedulerUnknownState, 0, 2, false, v1.RestartWhenReadyState),
+		Entry("restart when idle", v1.RestartWhenReadyState, v1.SchedulerRunningState, 1, 2, true, v1.Restarting),
+		Entry("restart when ready to restarting on scheduler failure", v1.RestartWhenReadyState, v1.SchedulerFailedState, 0, 2, false, v1.Restarting),
+		Entry("terminate when ready waits for running jobs", v1.TerminateWhenReady, v1.SchedulerRunningState, 2, 2, false, v1.TerminateWhenReady),
+		Entry("terminate when ready waits on unknown scheduler", v1.TerminateWhenReady, v1.SchedulerUnknownState, 0, 2, false, v1.TerminateWhenReady),
+		Entry("terminate when idle", v1.TerminateWhenReady, v1.SchedulerRunningState, 2, 2, true, v1.Terminating),
+		Entry("terminate when ready to terminating on scheduler failure", v1.TerminateWhenReady, v1.SchedulerFailedState, 0, 2, false, v1.Terminating),
+	)
+
+	DescribeTable("acceptRequestedOperation",
+		func(state v1.ClusterStateType, operation string, expected v1.ClusterStateType) {
+			cluster := &v1.BallistaCluster{}
+			cluster.Annotations = map[string]string{v1.RequestedOperationAnnotation: operation}
+			cluster.Status.ClusterState.State = state
+			Expect(acceptRequestedOperation(cluster, metav1.Now())).To(BeTrue())
+			Expect(cluster.Status.ClusterState.State).To(Equal(expected))
+		},
+		Entry("restart a running cluster", v1.RunningState, v1.RestartOperation, v1.RestartWhenReadyState),
+		Entry("terminate a pending cluster", v1.Pending, v1.TerminateOperation, v1.TerminateWhenReady),
+		Entry("terminate instead of restarting", v1.RestartWhenReadyState, v1.TerminateOperation, v1.TerminateWhenReady),
+		Entry("restart a terminated cluster", v1.Terminated, v1.RestartOperation, v1.Restarting),
+		Entry("terminate a terminated cluster", v1.Terminated, v1.TerminateOperation, v1.Terminated),
+		Entry("drop requests while restarting", v1.Restarting, v1.TerminateOperation, v1.Restarting),
+	)
+
+	It("keeps waiting from the first request", func() {
+		since := metav1.NewTime(time.Now().Add(-time.Minute))
+		cluster := &v1.BallistaCluster{}
+		cluster.Annotations = map[string]string{v1.RequestedOperationAnnotation: v1.TerminateOperation}
+		cluster.Status.ClusterState.State = v1.RestartWhenReadyState
+		cluster.Status.WhenReadySince = &since
+		Expect(acceptRequestedOperation(cluster, metav1.Now())).To(BeTrue())
+		Expect(cluster.Status.WhenReadySince).To(Equal(&since))
+
+		Expect(acceptRequestedOperation(&v1.BallistaCluster{}, metav1.Now())).To(BeFalse())
+	})
+
+	DescribeTable("whenReadyTimedOut",
+		func(waited time.Duration, timeoutSeconds int32, expected bool) {
+			now := time.Now()
+			since := metav1.NewTime(now.Add(-waited))
+			cluster := &v1.BallistaCluster{}
+			cluster.Spec.WhenReadyTimeoutSeconds = &timeoutSeconds
+			cluster.Status.WhenReadySince = &since
+			Expect(whenReadyTimedOut(cluster, now)).To(Equal(expected))
+		},
+		Entry("within the timeout", 30*time.Second, int32(60), false),
+		Entry("past the timeout", 2*time.Minute, int32(60), true),
+		Entry("without waiting", time.Duration(0), int32(0), true),
+	)
+
+	It("isClusterIdle stops waiting for running jobs once the timeout passed on the clock", func() {
+		fakeClock := clock.NewFakeClock(time.Now())
+		r := &BallistaClusterReconciler{SchedulerClient: &fakeSchedulerClient{activeJobs: 1}, Clock: fakeClock}
+		cluster := &v1.BallistaCluster{}
+		cluster.Spec.WhenReadyTimeoutSeconds = int32Ptr(60)
+		cluster.Status.SchedulerState = v1.SchedulerRunningState
+		cluster.Status.ClusterState.State = v1.TerminateWhenReady
+		since := metav1.NewTime(fakeClock.Now())
+		cluster.Status.WhenReadySince = &since
+
+		fakeClock.Step(59 * time.Second)
+		Expect(r.isClusterIdle(context.Background(), cluster, fakeClock.Now())).To(BeFalse())
+
+		fakeClock.Step(time.Second)
+		Expect(r.isClusterIdle(context.Background(), cluster, fakeClock.Now())).To(BeTrue())
+	})
+
+	DescribeTable("podPhaseToSchedulerState",
+		func(phase k8sapiv1.PodPhase, expected v1.SchedulerState) {
+			Expect(podPhaseToSchedulerState(phase)).To(Equal(expected))
+		},
+		Entry("pending", k8sapiv1.PodPending, v1.SchedulerPendingState),
+		Entry("running", k8sapiv1.PodRunning, v1.SchedulerRunningState),
+		Entry("succeeded", k8sapiv1.PodSucceeded, v1.SchedulerCompletedState),
+		Entry("failed", k8sapiv1.PodFailed, v1.SchedulerFailedState),
+		Entry("unknown", k8sapiv1.PodUnknown, v1.SchedulerUnknownState),
+	)
+
+	DescribeTable("podToExecutorState",
+		func(phase k8sapiv1.PodPhase, statuses []k8sapiv1.ContainerStatus, expected v1.ExecutorState) {
+			pod := &k8sapiv1.Pod{Status: k8sapiv1.PodStatus{Phase: phase, ContainerStatuses: statuses}}
+			Expect(podToExecutorState(pod)).To(Equal(expected))
+		},
+		Entry("scheduled", k8sapiv1.PodPending, nil, v1.ExecutorPendingState),
+		Entry("pulling the image", k8sapiv1.PodPending, []k8sapiv1.ContainerStatus{waitingContainer("ContainerCreating")}, v1.ExecutorPendingState),
+		Entry("unable to pull the image", k8sapiv1.PodPending, []k8sapiv1.ContainerStatus{waitingContainer("ImagePullBackOff")}, v1.ExecutorFailedState),
+		Entry("running and ready", k8sapiv1.PodRunning, []k8sapiv1.ContainerStatus{{Ready: true}}, v1.ExecutorRunningState),
+		Entry("running but not ready", k8sapiv1.PodRunning, []k8sapiv1.ContainerStatus{{Ready: true}, {Ready: false}}, v1.ExecutorPendingState),
+		Entry("crash looping", k8sapiv1.PodRunning, []k8sapiv1.ContainerStatus{waitingContainer("CrashLoopBackOff")}, v1.ExecutorFailedState),
+		Entry("completed", k8sapiv1.PodSucceeded, nil, v1.ExecutorCompletedState),
+		Entry("failed", k8sapiv1.PodFailed, nil, v1.ExecutorFailedState),
+		Entry("unknown", k8sapiv1.PodUnknown, nil, v1.ExecutorUnknownState),
+	)
+
+	DescribeTable("clusterStateRequeueInterval",
+		func(state v1.ClusterStateType, requeue bool) {
+			Expect(clusterStateRequeueInterval(state) > 0).To(Equal(requeue))
+		},
+		Entry("pending waits for pod events", v1.Pending, false),
+		Entry("running waits for pod events", v1.RunningState, false),
+		Entry("unknown is polled", v1.UnknownState, true),
+		Entry("restart when ready is polled", v1.RestartWhenReadyState, true),
+		Entry("terminate when ready is polled", v1.TerminateWhenReady, true),
+		Entry("restarting is polled", v1.Restarting, true),
+		Entry("terminating is polled", v1.Terminating, true),
+	)
+})
+
+var _ = Describe("BallistaCluster state handlers", func() {
+	var ctx context.Context
+	var r *BallistaClusterReconciler
+	name := types.NamespacedName{Name: "cluster", Namespace: "default"}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		cluster := &v1.BallistaCluster{ObjectMeta: metav1.ObjectMeta{Name: name.Name, Namespace: name.Namespace, UID: "cluster-uid"}}
+		cluster.Spec.Scheduler.Containers = []k8sapiv1.Container{{Name: v1.SchedulerContainerName, Image: "ballista:0.5.0"}}
+		cluster.Spec.Executor.Containers = []k8sapiv1.Container{{Name: v1.ExecutorContainerName, Image: "ballista:0.5.0"}}
+		cluster.Spec.Executor.Instances = int32Ptr(1)
+
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(v1.AddToScheme(scheme)).To(Succeed())
+		r = &BallistaClusterReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster).Build(),
+			Scheme:   scheme,
+			Recorder: record.NewFakeRecorder(50),
+			Clock:    clock.NewFakeClock(time.Now()),
+		}
+	})
+
+	reconcile := func() ctrl.Result {
+		result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: name})
+		Expect(err).NotTo(HaveOccurred())
+		return result
+	}
+
+	get := func() *v1.BallistaCluster {
+		cluster := &v1.BallistaCluster{}
+		Expect(r.Get(ctx, name, cluster)).To(Succeed())
+		return cluster
+	}
+
+	pods := func(role string) []k8sapiv1.Pod {
+		pods, err := r.listClusterPods(ctx, get(), role)
+		Expect(err).NotTo(HaveOccurred())
+		return pods
+	}
+
+	// run makes every pod of the role run and be ready.
+	run := func(role string) {
+		for _, pod := range pods(role) {
+			pod.Status = k8sapiv1.PodStatus{
+				Phase:             k8sapiv1.PodRunning,
+				Conditions:        []k8sapiv1.PodCondition{{Type: k8sapiv1.PodReady, Status: k8sapiv1.ConditionTrue}},
+				ContainerStatuses: []k8sapiv1.ContainerStatus{{Ready: true}},
+			}
+			Expect(r.Status().Update(ctx, &pod)).To(Succeed())
+		}
+	}
+
+	// moveTo persists the cluster state, as a previous reconciliation would have.
+	moveTo := func(state v1.ClusterStateType) {
+		cluster := get()
+		cluster.Status.ClusterState.State = state
+		Expect(r.Status().Update(ctx, cluster)).To(Succeed())
+	}
+
+	// start brings the cluster up to Running.
+	start := func() {
+		reconcile()
+		run(schedulerRole)
+		reconcile()
+		run(executorRole)
+		reconcile()
+		Expect(get().Status.ClusterState.State).To(Equal(v1.RunningState))
+	}
+
+	It("starts a new cluster and runs it once its pods are ready", func() {
+		reconcile()
+		cluster := get()
+		Expect(cluster.Status.ClusterState.State).To(Equal(v1.Pending))
+		Expect(cluster.Status.ClusterID).NotTo(BeEmpty())
+		Expect(cluster.Finalizers).To(ContainElement(ballistaClusterFinalizer))
+		Expect(pods(schedulerRole)).To(HaveLen(1))
+		Expect(pods(executorRole)).To(BeEmpty())
+
+		// executors are only created once the scheduler runs
+		run(schedulerRole)
+		reconcile()
+		cluster = get()
+		Expect(cluster.Status.ClusterState.State).To(Equal(v1.Pending))
+		Expect(cluster.Status.SchedulerState).To(Equal(v1.SchedulerRunningState))
+		Expect(pods(executorRole)).To(HaveLen(1))
+
+		run(executorRole)
+		reconcile()
+		cluster = get()
+		Expect(cluster.Status.ClusterState.State).To(Equal(v1.RunningState))
+		Expect(cluster.Status.ReadyExecutors).To(Equal(int32(1)))
+		Expect(cluster.Status.ClusterState.ErrorMessage).To(BeEmpty())
+	})
+
+	It("restarts a cluster whose scheduler failed", func() {
+		start()
+		clusterID := get().Status.ClusterID
+		scheduler := pods(schedulerRole)[0]
+		scheduler.Status.Phase = k8sapiv1.PodFailed
+		scheduler.Status.Message = "out of memory"
+		Expect(r.Status().Update(ctx, &scheduler)).To(Succeed())
+
+		reconcile()
+		cluster := get()
+		Expect(cluster.Status.ClusterState.State).To(Equal(v1.Restarting))
+		Expect(cluster.Status.ClusterState.ErrorMessage).To(ContainSubstring("out of memory"))
+
+		// the executors are deleted first, then the scheduler, and the cluster starts over once they are gone
+		Expect(reconcile().RequeueAfter).To(Equal(terminationPollInterval))
+		Expect(pods(executorRole)).To(BeEmpty())
+		Expect(reconcile().RequeueAfter).To(Equal(terminationPollInterval))
+		Expect(pods(schedulerRole)).To(BeEmpty())
+		reconcile()
+		cluster = get()
+		Expect(cluster.Status.ClusterState.State).To(Equal(v1.Pending))
+		Expect(cluster.Status.ClusterID).To(Equal(clusterID))
+		Expect(cluster.Status.ReadyExecutors).To(BeZero())
+		Expect(pods(schedulerRole)).To(HaveLen(1))
+	})
+
+	It("terminates a cluster and tears down its resources", func() {
+		start()
+		moveTo(v1.Terminating)
+
+		Expect(reconcile().RequeueAfter).To(Equal(terminationPollInterval))
+		Expect(pods(executorRole)).To(BeEmpty())
+		Expect(get().Status.ClusterState.State).To(Equal(v1.Terminating))
+		// then the scheduler, and the other resources of the cluster
+		Expect(reconcile().RequeueAfter).To(Equal(terminationPollInterval))
+		Expect(pods(schedulerRole)).To(BeEmpty())
+		Expect(reconcile().RequeueAfter).To(Equal(terminationPollInterval))
+		reconcile()
+		cluster := get()
+		Expect(cluster.Status.ClusterState.State).To(Equal(v1.Terminated))
+		Expect(cluster.Status.TerminationTime).NotTo(BeNil())
+		Expect(cluster.Status.ReadyExecutors).To(BeZero())
+		Expect(pods(schedulerRole)).To(BeEmpty())
+		services := &k8sapiv1.ServiceList{}
+		Expect(r.List(ctx, services)).To(Succeed())
+		Expect(services.Items).To(BeEmpty())
+
+		// a terminated cluster stays down
+		Expect(reconcile()).To(Equal(ctrl.Result{}))
+		Expect(get().Status.ClusterState.State).To(Equal(v1.Terminated))
+		Expect(pods(schedulerRole)).To(BeEmpty())
+	})
+})
+
+func waitingContainer(reason string) k8sapiv1.ContainerStatus {
+	return k8sapiv1.ContainerStatus{State: k8sapiv1.ContainerState{Waiting: &k8sapiv1.ContainerStateWaiting{Reason: reason}}}
+}
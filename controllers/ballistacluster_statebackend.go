@@ -0,0 +1,170 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	k8sapiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+const (
+	// stateVolumeName is the name of the volume holding the Sled database of the scheduler.
+	stateVolumeName = "ballista-state"
+	// stateMountPath is the directory the Sled database of the scheduler lives in.
+	stateMountPath = "/var/lib/ballista/state"
+	// etcdTLSVolumeName is the name of the volume holding the certificates the scheduler reaches etcd with.
+	etcdTLSVolumeName = "etcd-tls"
+	// etcdTLSMountPath is the directory the etcd certificates are mounted in.
+	etcdTLSMountPath = "/var/run/secrets/ballista/etcd"
+)
+
+// reconcileStateVolumeClaim provisions the PersistentVolumeClaim of the Sled state backend of the scheduler.
+// The claim is left alone once it exists, as its spec cannot change.
+func (r *BallistaClusterReconciler) reconcileStateVolumeClaim(ctx context.Context, cluster *v1.BallistaCluster) error {
+	log := log.FromContext(ctx)
+
+	backend := cluster.Spec.Scheduler.StateBackend
+	if backend == nil || backend.Type != v1.SledStateBackend {
+		return nil
+	}
+	claim, err := r.newStateVolumeClaim(cluster)
+	if err != nil {
+		return err
+	}
+	if err := r.Create(ctx, claim); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			return nil
+		}
+		log.Error(err, "unable to create state volume claim for Ballista Cluster", "claim", claim.Name)
+		return err
+	}
+	log.Info("created state volume claim", "claim", claim.Name)
+	return nil
+}
+
+// newStateVolumeClaim builds the PersistentVolumeClaim of the Sled state backend of the scheduler.
+func (r *BallistaClusterReconciler) newStateVolumeClaim(cluster *v1.BallistaCluster) (*k8sapiv1.PersistentVolumeClaim, error) {
+	size := resource.MustParse(v1.DefaultSledVolumeSize)
+	var storageClassName *string
+	if sled := cluster.Spec.Scheduler.StateBackend.Sled; sled != nil {
+		if sled.Size != nil {
+			size = sled.Size.DeepCopy()
+		}
+		storageClassName = sled.StorageClassName
+	}
+
+	claim := &k8sapiv1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:    clusterLabels(cluster, schedulerRole),
+			Name:      stateVolumeClaimName(cluster),
+			Namespace: cluster.Namespace,
+		},
+		Spec: k8sapiv1.PersistentVolumeClaimSpec{
+			AccessModes:      []k8sapiv1.PersistentVolumeAccessMode{k8sapiv1.ReadWriteOnce},
+			StorageClassName: storageClassName,
+			Resources: k8sapiv1.ResourceRequirements{
+				Requests: k8sapiv1.ResourceList{k8sapiv1.ResourceStorage: size},
+			},
+		},
+	}
+	if err := ctrl.SetControllerReference(cluster, claim, r.Scheme); err != nil {
+		return nil, err
+	}
+	return claim, nil
+}
+
+// stateBackendSettings returns the scheduler settings selecting its state backend. The memory backend is
+// the default of the scheduler and needs none.
+func stateBackendSettings(cluster *v1.BallistaCluster) map[string]string {
+	settings := make(map[string]string)
+	backend := cluster.Spec.Scheduler.StateBackend
+	if backend == nil {
+		return settings
+	}
+	switch backend.Type {
+	case v1.SledStateBackend:
+		settings["config_backend"] = strconv.Quote("standalone")
+		settings["sled_dir"] = strconv.Quote(stateMountPath)
+	case v1.EtcdStateBackend:
+		if backend.Etcd == nil {
+			break
+		}
+		settings["config_backend"] = strconv.Quote("etcd")
+		settings["etcd_urls"] = strconv.Quote(strings.Join(backend.Etcd.Endpoints, ","))
+		if backend.Etcd.TLSSecretName != nil {
+			settings["etcd_ca_file"] = strconv.Quote(path.Join(etcdTLSMountPath, "ca.crt"))
+			settings["etcd_cert_file"] = strconv.Quote(path.Join(etcdTLSMountPath, k8sapiv1.TLSCertKey))
+			settings["etcd_key_file"] = strconv.Quote(path.Join(etcdTLSMountPath, k8sapiv1.TLSPrivateKeyKey))
+		}
+	}
+	return settings
+}
+
+// mountStateBackend mounts the volume the state backend of the scheduler needs, if any, into the first
+// container of the scheduler pod template.
+func mountStateBackend(cluster *v1.BallistaCluster, template *k8sapiv1.PodTemplateSpec) {
+	backend := cluster.Spec.Scheduler.StateBackend
+	if backend == nil {
+		return
+	}
+
+	var volume k8sapiv1.Volume
+	var mount k8sapiv1.VolumeMount
+	switch {
+	case backend.Type == v1.SledStateBackend:
+		volume = k8sapiv1.Volume{
+			Name: stateVolumeName,
+			VolumeSource: k8sapiv1.VolumeSource{
+				PersistentVolumeClaim: &k8sapiv1.PersistentVolumeClaimVolumeSource{ClaimName: stateVolumeClaimName(cluster)},
+			},
+		}
+		mount = k8sapiv1.VolumeMount{Name: stateVolumeName, MountPath: stateMountPath}
+	case backend.Type == v1.EtcdStateBackend && backend.Etcd != nil && backend.Etcd.TLSSecretName != nil:
+		volume = k8sapiv1.Volume{
+			Name: etcdTLSVolumeName,
+			VolumeSource: k8sapiv1.VolumeSource{
+				Secret: &k8sapiv1.SecretVolumeSource{SecretName: *backend.Etcd.TLSSecretName},
+			},
+		}
+		mount = k8sapiv1.VolumeMount{Name: etcdTLSVolumeName, MountPath: etcdTLSMountPath, ReadOnly: true}
+	default:
+		return
+	}
+
+	spec := &template.Spec
+	spec.Volumes = append(spec.Volumes, volume)
+	if len(spec.Containers) > 0 {
+		spec.Containers[0].VolumeMounts = append(spec.Containers[0].VolumeMounts, mount)
+	}
+}
+
+// stateVolumeClaimName returns the name of the PersistentVolumeClaim of the Sled state backend of the cluster.
+func stateVolumeClaimName(cluster *v1.BallistaCluster) string {
+	return fmt.Sprintf("%s-%s-state", cluster.Name, schedulerRole)
+}
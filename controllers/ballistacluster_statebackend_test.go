@@ -0,0 +1,92 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	k8sapiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+var _ = Describe("BallistaCluster state backend", func() {
+	var cluster *v1.BallistaCluster
+
+	BeforeEach(func() {
+		cluster = &v1.BallistaCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"}}
+		cluster.Spec.Scheduler.Containers = []k8sapiv1.Container{{Name: "scheduler", Image: "ballista:0.5.0"}}
+	})
+
+	It("leaves the in-memory scheduler alone", func() {
+		cluster.Spec.Scheduler.StateBackend = &v1.StateBackend{Type: v1.MemoryStateBackend}
+		Expect(schedulerConfig(cluster)).To(BeEmpty())
+		Expect(schedulerPodTemplate(cluster).Spec.Volumes).To(HaveLen(1))
+	})
+
+	It("keeps the Sled database on the claim of the cluster", func() {
+		size := resource.MustParse("5Gi")
+		cluster.Spec.Scheduler.StateBackend = &v1.StateBackend{Type: v1.SledStateBackend, Sled: &v1.SledBackend{Size: &size}}
+		Expect(schedulerConfig(cluster)).To(Equal(`config_backend = "standalone"
+sled_dir = "/var/lib/ballista/state"
+`))
+
+		template := schedulerPodTemplate(cluster)
+		Expect(template.Spec.Volumes).To(ContainElement(k8sapiv1.Volume{
+			Name: stateVolumeName,
+			VolumeSource: k8sapiv1.VolumeSource{
+				PersistentVolumeClaim: &k8sapiv1.PersistentVolumeClaimVolumeSource{ClaimName: "cluster-scheduler-state"},
+			},
+		}))
+		Expect(template.Spec.Containers[0].VolumeMounts).To(ContainElement(
+			k8sapiv1.VolumeMount{Name: stateVolumeName, MountPath: stateMountPath}))
+
+		scheme := runtime.NewScheme()
+		Expect(v1.AddToScheme(scheme)).To(Succeed())
+		r := &BallistaClusterReconciler{Scheme: scheme}
+		claim, err := r.newStateVolumeClaim(cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(claim.Name).To(Equal("cluster-scheduler-state"))
+		Expect(claim.Spec.Resources.Requests.Storage().String()).To(Equal("5Gi"))
+		Expect(metav1.IsControlledBy(claim, cluster)).To(BeTrue())
+	})
+
+	It("reaches etcd with the certificates of the TLS secret", func() {
+		secret := "etcd-client"
+		cluster.Spec.Scheduler.StateBackend = &v1.StateBackend{
+			Type: v1.EtcdStateBackend,
+			Etcd: &v1.EtcdBackend{Endpoints: []string{"https://etcd-0:2379", "https://etcd-1:2379"}, TLSSecretName: &secret},
+		}
+		Expect(schedulerConfig(cluster)).To(Equal(`config_backend = "etcd"
+etcd_ca_file = "/var/run/secrets/ballista/etcd/ca.crt"
+etcd_cert_file = "/var/run/secrets/ballista/etcd/tls.crt"
+etcd_key_file = "/var/run/secrets/ballista/etcd/tls.key"
+etcd_urls = "https://etcd-0:2379,https://etcd-1:2379"
+`))
+
+		template := schedulerPodTemplate(cluster)
+		Expect(template.Spec.Volumes).To(ContainElement(k8sapiv1.Volume{
+			Name:         etcdTLSVolumeName,
+			VolumeSource: k8sapiv1.VolumeSource{Secret: &k8sapiv1.SecretVolumeSource{SecretName: secret}},
+		}))
+		Expect(template.Spec.Containers[0].VolumeMounts).To(ContainElement(
+			k8sapiv1.VolumeMount{Name: etcdTLSVolumeName, MountPath: etcdTLSMountPath, ReadOnly: true}))
+	})
+})
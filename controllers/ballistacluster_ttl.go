@@ -0,0 +1,93 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	k8sapiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+// expireIdleCluster moves an ephemeral cluster whose scheduler ran no jobs for Spec.TTLSecondsAfterIdle to
+// TerminateWhenReady, the way a requested termination does, and returns whether it did.
+func (r *BallistaClusterReconciler) expireIdleCluster(ctx context.Context, cluster *v1.BallistaCluster, now time.Time) bool {
+	ttl := cluster.Spec.TTLSecondsAfterIdle
+	if ttl == nil || cluster.Status.IdleSince == nil {
+		return false
+	}
+	switch cluster.Status.ClusterState.State {
+	case v1.Pending, v1.RunningState, v1.UnknownState:
+	default:
+		// the cluster is already on its way to a restart or a termination
+		return false
+	}
+	timeout := time.Duration(*ttl) * time.Second
+	if cluster.Status.IdleSince.Add(timeout).After(now) {
+		return false
+	}
+
+	log.FromContext(ctx).Info("terminating idle ephemeral cluster", "idleSince", cluster.Status.IdleSince)
+	cluster.Status.ClusterState.State = v1.TerminateWhenReady
+	whenReadySince := metav1.NewTime(now)
+	cluster.Status.WhenReadySince = &whenReadySince
+	r.Recorder.Eventf(cluster, k8sapiv1.EventTypeNormal, eventIdleTTLExpired, "Terminating the cluster after %s without jobs", timeout)
+	return true
+}
+
+// reconcileTerminatedCluster leaves a terminated cluster alone, unless it is ephemeral: it is then deleted
+// once Spec.TTLSecondsAfterFinished elapsed since its termination.
+func (r *BallistaClusterReconciler) reconcileTerminatedCluster(ctx context.Context, cluster *v1.BallistaCluster, now time.Time) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	ttl, ephemeral := finishedTTL(cluster)
+	if !ephemeral {
+		return ctrl.Result{}, nil
+	}
+	if cluster.Status.TerminationTime == nil {
+		// terminated before it was made ephemeral, the TTL starts now
+		terminationTime := metav1.NewTime(now)
+		cluster.Status.TerminationTime = &terminationTime
+		return ctrl.Result{RequeueAfter: ttl, Requeue: true}, nil
+	}
+	if remaining := cluster.Status.TerminationTime.Add(ttl).Sub(now); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	log.Info("deleting terminated ephemeral cluster", "terminationTime", cluster.Status.TerminationTime)
+	if err := r.Delete(ctx, cluster); client.IgnoreNotFound(err) != nil {
+		log.Error(err, "unable to delete terminated ephemeral BallistaCluster")
+		return ctrl.Result{}, err
+	}
+	r.Recorder.Eventf(cluster, k8sapiv1.EventTypeNormal, eventFinishedTTLExpired, "Deleted the cluster %s after its termination", ttl)
+	return ctrl.Result{}, nil
+}
+
+// finishedTTL returns how long a terminated cluster is kept before it is deleted, and whether the cluster is
+// ephemeral at all.
+func finishedTTL(cluster *v1.BallistaCluster) (time.Duration, bool) {
+	if ttl := cluster.Spec.TTLSecondsAfterFinished; ttl != nil {
+		return time.Duration(*ttl) * time.Second, true
+	}
+	return 0, cluster.Spec.TTLSecondsAfterIdle != nil
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+var _ = Describe("BallistaCluster TTL", func() {
+	var cluster *v1.BallistaCluster
+	var scheduler *fakeSchedulerClient
+	var recorder *record.FakeRecorder
+	var r *BallistaClusterReconciler
+
+	BeforeEach(func() {
+		cluster = &v1.BallistaCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"}}
+		cluster.Spec.Executor.Instances = int32Ptr(3)
+		cluster.Spec.TTLSecondsAfterIdle = int32Ptr(600)
+		cluster.Status.ClusterState.State = v1.RunningState
+		cluster.Status.SchedulerState = v1.SchedulerRunningState
+		scheduler = &fakeSchedulerClient{}
+		recorder = record.NewFakeRecorder(10)
+		r = &BallistaClusterReconciler{SchedulerClient: scheduler, Recorder: recorder, Clock: clock.NewFakeClock(time.Now())}
+	})
+
+	idleFor := func(idle time.Duration) {
+		since := metav1.NewTime(r.Clock.Now().Add(-idle))
+		cluster.Status.IdleSince = &since
+	}
+
+	It("starts the idle clock without an idle timeout", func() {
+		r.reconcileIdleness(context.Background(), cluster, r.Clock.Now())
+		Expect(cluster.Status.IdleSince).NotTo(BeNil())
+		Expect(cluster.Status.ClusterState.State).To(Equal(v1.RunningState))
+	})
+
+	It("terminates a cluster idle for its TTL", func() {
+		idleFor(11 * time.Minute)
+		r.reconcileIdleness(context.Background(), cluster, r.Clock.Now())
+		Expect(cluster.Status.ClusterState.State).To(Equal(v1.TerminateWhenReady))
+		Expect(cluster.Status.WhenReadySince).NotTo(BeNil())
+		Expect(recorder.Events).To(Receive(ContainSubstring(eventIdleTTLExpired)))
+	})
+
+	It("terminates once the TTL passed on the clock", func() {
+		fakeClock := r.Clock.(*clock.FakeClock)
+		r.reconcileIdleness(context.Background(), cluster, fakeClock.Now())
+
+		fakeClock.Step(10*time.Minute - time.Second)
+		r.reconcileIdleness(context.Background(), cluster, fakeClock.Now())
+		Expect(cluster.Status.ClusterState.State).To(Equal(v1.RunningState))
+
+		fakeClock.Step(time.Second)
+		r.reconcileIdleness(context.Background(), cluster, fakeClock.Now())
+		Expect(cluster.Status.ClusterState.State).To(Equal(v1.TerminateWhenReady))
+		Expect(cluster.Status.WhenReadySince.Time).To(Equal(fakeClock.Now()))
+	})
+
+	It("keeps a cluster running jobs", func() {
+		idleFor(11 * time.Minute)
+		scheduler.activeJobs = 1
+		r.reconcileIdleness(context.Background(), cluster, r.Clock.Now())
+		Expect(cluster.Status.ClusterState.State).To(Equal(v1.RunningState))
+		Expect(cluster.Status.IdleSince).To(BeNil())
+	})
+
+	It("leaves a restarting cluster alone", func() {
+		idleFor(11 * time.Minute)
+		cluster.Status.ClusterState.State = v1.RestartWhenReadyState
+		r.reconcileIdleness(context.Background(), cluster, r.Clock.Now())
+		Expect(cluster.Status.ClusterState.State).To(Equal(v1.RestartWhenReadyState))
+	})
+
+	Describe("reconcileTerminatedCluster", func() {
+		BeforeEach(func() {
+			scheme := runtime.NewScheme()
+			Expect(v1.AddToScheme(scheme)).To(Succeed())
+			cluster.Status.ClusterState.State = v1.Terminated
+			cluster.Spec.TTLSecondsAfterFinished = int32Ptr(300)
+			r.Client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster).Build()
+			r.Scheme = scheme
+		})
+
+		terminatedFor := func(terminated time.Duration) {
+			since := metav1.NewTime(time.Now().Add(-terminated))
+			cluster.Status.TerminationTime = &since
+		}
+
+		exists := func() bool {
+			err := r.Get(context.Background(), types.NamespacedName{Name: "cluster", Namespace: "default"}, &v1.BallistaCluster{})
+			if apierrors.IsNotFound(err) {
+				return false
+			}
+			Expect(err).NotTo(HaveOccurred())
+			return true
+		}
+
+		It("keeps a cluster until its TTL after finished", func() {
+			terminatedFor(time.Minute)
+			result, err := r.reconcileTerminatedCluster(context.Background(), cluster, time.Now())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically("~", 4*time.Minute, time.Second))
+			Expect(exists()).To(BeTrue())
+		})
+
+		It("deletes a cluster past its TTL after finished", func() {
+			terminatedFor(6 * time.Minute)
+			_, err := r.reconcileTerminatedCluster(context.Background(), cluster, time.Now())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exists()).To(BeFalse())
+			Expect(recorder.Events).To(Receive(ContainSubstring(eventFinishedTTLExpired)))
+		})
+
+		It("starts the TTL of a cluster terminated before it was ephemeral", func() {
+			_, err := r.reconcileTerminatedCluster(context.Background(), cluster, time.Now())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cluster.Status.TerminationTime).NotTo(BeNil())
+			Expect(exists()).To(BeTrue())
+		})
+
+		It("deletes a cluster only ephemeral when idle right away", func() {
+			cluster.Spec.TTLSecondsAfterFinished = nil
+			terminatedFor(time.Second)
+			_, err := r.reconcileTerminatedCluster(context.Background(), cluster, time.Now())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exists()).To(BeFalse())
+		})
+
+		It("keeps a cluster that is not ephemeral", func() {
+			cluster.Spec.TTLSecondsAfterIdle = nil
+			cluster.Spec.TTLSecondsAfterFinished = nil
+			terminatedFor(time.Hour)
+			_, err := r.reconcileTerminatedCluster(context.Background(), cluster, time.Now())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exists()).To(BeTrue())
+		})
+	})
+})
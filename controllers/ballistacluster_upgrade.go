@@ -0,0 +1,270 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	k8sapiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+// upgradePollInterval is how often a rolling upgrade is checked for progress.
+const upgradePollInterval = 10 * time.Second
+
+// reconcileUpgrade replaces the pods running an outdated pod template: the scheduler first, then the
+// executors in batches of Spec.UpgradeStrategy.ExecutorBatchSize, decommissioning the outdated ones, each
+// step waiting for the replaced pods to become ready. An upgrade whose pods do not become ready in time
+// halts until the templates change again. It returns how long to wait before checking the upgrade again,
+// zero when there is nothing to wait for.
+func (r *BallistaClusterReconciler) reconcileUpgrade(ctx context.Context, cluster *v1.BallistaCluster, now time.Time) (time.Duration, error) {
+	log := log.FromContext(ctx)
+
+	schedulerTemplate := schedulerPodTemplate(cluster)
+	schedulerHash := podTemplateHash(&schedulerTemplate)
+	executorHashes := executorTemplateHashes(cluster)
+	templates := schedulerHash + "/" + executorHashes[""]
+	for _, pool := range cluster.Spec.ExecutorPools {
+		templates += "/" + pool.Name + "=" + executorHashes[pool.Name]
+	}
+	templateHash := rand.SafeEncodeString(fmt.Sprint(hashString(templates)))
+
+	schedulers, err := r.listClusterPods(ctx, cluster, schedulerRole)
+	if err != nil {
+		log.Error(err, "unable to list scheduler pods")
+		return 0, err
+	}
+	executors, err := r.listClusterPods(ctx, cluster, executorRole)
+	if err != nil {
+		log.Error(err, "unable to list executor pods")
+		return 0, err
+	}
+	outdatedSchedulers, updatedSchedulers := partitionPodsByHash(schedulers, schedulerHash)
+	outdatedExecutors, updatedExecutors := partitionExecutorsByHash(executors, executorHashes)
+
+	upgrade := cluster.Status.Upgrade
+	if upgrade == nil || upgrade.TemplateHash != templateHash {
+		if len(outdatedSchedulers) == 0 && len(outdatedExecutors) == 0 {
+			// every pod already runs the current templates
+			cluster.Status.CurrentVersion = cluster.Spec.BallistaVersion
+			return 0, nil
+		}
+		log.Info("starting rolling upgrade", "version", cluster.Spec.BallistaVersion, "templateHash", templateHash)
+		upgrade = &v1.UpgradeStatus{
+			Phase:         v1.UpgradingSchedulerPhase,
+			TargetVersion: cluster.Spec.BallistaVersion,
+			TemplateHash:  templateHash,
+			StartTime:     metav1.NewTime(now),
+		}
+		cluster.Status.Upgrade = upgrade
+		r.Recorder.Eventf(cluster, k8sapiv1.EventTypeNormal, eventUpgradeStarted, "Started rolling upgrade to version %s",
+			upgrade.TargetVersion)
+	}
+	upgrade.UpdatedExecutors = int32(len(updatedExecutors))
+
+	timeout := readinessTimeout(cluster)
+	switch upgrade.Phase {
+	case v1.UpgradingSchedulerPhase:
+		// the replicas are replaced one at a time, each waiting for the previous replacement to become ready
+		for i := range updatedSchedulers {
+			scheduler := &updatedSchedulers[i]
+			if isPodReady(scheduler) {
+				continue
+			}
+			if podReadinessTimedOut(scheduler, timeout, now) {
+				r.failUpgrade(cluster, fmt.Sprintf("scheduler pod %s did not become ready within %s", scheduler.Name, timeout))
+				return 0, nil
+			}
+			return upgradePollInterval, nil
+		}
+		replicas := schedulerReplicas(cluster)
+		if len(outdatedSchedulers) > 0 {
+			if len(outdatedSchedulers)+len(updatedSchedulers) < replicas {
+				// the replacement is created once the old replica is gone
+				return upgradePollInterval, nil
+			}
+			if err := r.deleteIfNotDeleting(ctx, &outdatedSchedulers[0]); err != nil {
+				return 0, err
+			}
+			return upgradePollInterval, nil
+		}
+		if len(updatedSchedulers) < replicas {
+			return upgradePollInterval, nil
+		}
+		log.Info("upgraded scheduler, upgrading executors", "replicas", len(updatedSchedulers))
+		upgrade.Phase = v1.UpgradingExecutorsPhase
+		fallthrough
+
+	case v1.UpgradingExecutorsPhase:
+		for i := range updatedExecutors {
+			executor := &updatedExecutors[i]
+			if isPodReady(executor) {
+				continue
+			}
+			if podReadinessTimedOut(executor, timeout, now) {
+				r.failUpgrade(cluster, fmt.Sprintf("executor pod %s did not become ready within %s", executor.Name, timeout))
+				return 0, nil
+			}
+			return upgradePollInterval, nil
+		}
+		if len(outdatedExecutors) == 0 {
+			log.Info("completed rolling upgrade", "version", upgrade.TargetVersion)
+			upgrade.Phase = v1.UpgradeCompletedPhase
+			cluster.Status.CurrentVersion = upgrade.TargetVersion
+			r.Recorder.Eventf(cluster, k8sapiv1.EventTypeNormal, eventUpgradeCompleted, "Completed rolling upgrade to version %s",
+				upgrade.TargetVersion)
+			return 0, nil
+		}
+
+		// the outdated executors are decommissioned like on scale-down, so that their tasks get to finish, and
+		// count against the batch until they are gone
+		decommissioning := 0
+		for i := range executors {
+			if isPodActive(&executors[i]) && isExecutorDecommissioning(&executors[i]) {
+				decommissioning++
+			}
+		}
+		batch := executorUpgradeBatch(len(outdatedExecutors), len(outdatedExecutors)+len(updatedExecutors),
+			decommissioning, executorInstances(cluster), upgradeBatchSize(cluster))
+		if batch > 0 {
+			registered := r.registeredExecutors(ctx, cluster)
+			victims := executorsToRemove(outdatedExecutors, batch, registered)
+			if err := r.decommissionExecutors(ctx, cluster, victims, registered, "on upgrade", now); err != nil {
+				return 0, err
+			}
+		}
+		return upgradePollInterval, nil
+
+	case v1.UpgradeFailedPhase:
+		// the upgrade stays halted until the templates change again
+		cluster.Status.ClusterState.ErrorMessage = upgrade.Message
+	}
+	return 0, nil
+}
+
+// failUpgrade halts the upgrade of the cluster with the given reason.
+func (r *BallistaClusterReconciler) failUpgrade(cluster *v1.BallistaCluster, reason string) {
+	upgrade := cluster.Status.Upgrade
+	upgrade.Phase = v1.UpgradeFailedPhase
+	upgrade.Message = fmt.Sprintf("upgrade to version %s halted: %s", upgrade.TargetVersion, reason)
+	cluster.Status.ClusterState.ErrorMessage = upgrade.Message
+	r.Recorder.Event(cluster, k8sapiv1.EventTypeWarning, eventUpgradeFailed, upgrade.Message)
+}
+
+// partitionPodsByHash splits the active pods, leaving out decommissioning executors, into those created from
+// another pod template than the one with the given hash and those created from it.
+func partitionPodsByHash(pods []k8sapiv1.Pod, hash string) (outdated, updated []k8sapiv1.Pod) {
+	for i := range pods {
+		if !isPodActive(&pods[i]) || isExecutorDecommissioning(&pods[i]) {
+			continue
+		}
+		if pods[i].Labels[podTemplateHashKey] == hash {
+			updated = append(updated, pods[i])
+		} else {
+			outdated = append(outdated, pods[i])
+		}
+	}
+	return outdated, updated
+}
+
+// partitionExecutorsByHash splits the active executor pods like partitionPodsByHash does, against the template
+// hash of their pool. The executors of pools removed from the spec are left out, as they are decommissioned.
+func partitionExecutorsByHash(executors []k8sapiv1.Pod, hashes map[string]string) (outdated, updated []k8sapiv1.Pod) {
+	for i := range executors {
+		hash, ok := hashes[executors[i].Labels[podExecutorPoolKey]]
+		if !ok {
+			continue
+		}
+		poolOutdated, poolUpdated := partitionPodsByHash(executors[i:i+1], hash)
+		outdated = append(outdated, poolOutdated...)
+		updated = append(updated, poolUpdated...)
+	}
+	return outdated, updated
+}
+
+// executorTemplateHashes returns the hash of the executor pod template of every executor pool of the cluster,
+// keyed by pool name.
+func executorTemplateHashes(cluster *v1.BallistaCluster) map[string]string {
+	pools := executorPools(cluster)
+	hashes := make(map[string]string, len(pools))
+	for _, pool := range pools {
+		template := executorPoolPodTemplate(cluster, pool.name)
+		hashes[pool.name] = podTemplateHash(&template)
+	}
+	return hashes
+}
+
+// executorUpgradeBatch returns how many outdated executors to replace now. Replacement waits until the
+// cluster is back to its desired size, and the executors still decommissioning take up their place in the
+// batch, so that at most a batch of executors is being replaced at any time.
+func executorUpgradeBatch(outdated, active, decommissioning, desired, batchSize int) int {
+	if active < desired {
+		return 0
+	}
+	batch := batchSize - decommissioning
+	if batch > outdated {
+		return outdated
+	}
+	if batch < 0 {
+		return 0
+	}
+	return batch
+}
+
+// podReadinessTimedOut tells whether a pod has had more than the given time to become ready.
+func podReadinessTimedOut(pod *k8sapiv1.Pod, timeout time.Duration, now time.Time) bool {
+	return pod.CreationTimestamp.Add(timeout).Before(now)
+}
+
+// podTemplateHash returns a label-safe hash of the pod template, identifying the template pods were created from.
+func podTemplateHash(template *k8sapiv1.PodTemplateSpec) string {
+	// marshaling a PodTemplateSpec cannot fail
+	data, _ := json.Marshal(template)
+	return rand.SafeEncodeString(fmt.Sprint(hashString(string(data))))
+}
+
+// hashString returns the 32-bit FNV-1a hash of the string.
+func hashString(s string) uint32 {
+	hasher := fnv.New32a()
+	hasher.Write([]byte(s))
+	return hasher.Sum32()
+}
+
+// upgradeBatchSize returns the number of executors replaced at a time, defaulting to one.
+func upgradeBatchSize(cluster *v1.BallistaCluster) int {
+	if cluster.Spec.UpgradeStrategy.ExecutorBatchSize == nil {
+		return int(v1.DefaultExecutorBatchSize)
+	}
+	return int(*cluster.Spec.UpgradeStrategy.ExecutorBatchSize)
+}
+
+// readinessTimeout returns how long a replaced pod has to become ready.
+func readinessTimeout(cluster *v1.BallistaCluster) time.Duration {
+	seconds := v1.DefaultReadinessTimeoutSeconds
+	if cluster.Spec.UpgradeStrategy.ReadinessTimeoutSeconds != nil {
+		seconds = *cluster.Spec.UpgradeStrategy.ReadinessTimeoutSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
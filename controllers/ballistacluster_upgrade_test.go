@@ -0,0 +1,214 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	k8sapiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+var _ = Describe("BallistaCluster rolling upgrade", func() {
+
+	Context("podTemplateHash", func() {
+		It("changes with the image only", func() {
+			cluster := &v1.BallistaCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"}}
+			cluster.Spec.Executor.Containers = []k8sapiv1.Container{{Name: "executor", Image: "ballista:0.5.0"}}
+			template := executorPodTemplate(cluster)
+			hash := podTemplateHash(&template)
+			Expect(podTemplateHash(&template)).To(Equal(hash))
+
+			cluster.Spec.Executor.Containers[0].Image = "ballista:0.6.0"
+			template = executorPodTemplate(cluster)
+			Expect(podTemplateHash(&template)).NotTo(Equal(hash))
+		})
+
+		It("changes with the configuration", func() {
+			cluster := &v1.BallistaCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"}}
+			cluster.Spec.Executor.Containers = []k8sapiv1.Container{{Name: "executor", Image: "ballista:0.5.0"}}
+			template := executorPodTemplate(cluster)
+			hash := podTemplateHash(&template)
+
+			cluster.Spec.Executor.Config = &v1.ExecutorConfig{ConcurrentTasks: int32Ptr(8)}
+			template = executorPodTemplate(cluster)
+			Expect(podTemplateHash(&template)).NotTo(Equal(hash))
+			Expect(template.Annotations).To(HaveKey(configChecksumAnnotation))
+		})
+	})
+
+	It("partitions active pods by template hash", func() {
+		pod := func(name, hash string, phase k8sapiv1.PodPhase) k8sapiv1.Pod {
+			return k8sapiv1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{podTemplateHashKey: hash}},
+				Status:     k8sapiv1.PodStatus{Phase: phase},
+			}
+		}
+		outdated, updated := partitionPodsByHash([]k8sapiv1.Pod{
+			pod("old", "a", k8sapiv1.PodRunning),
+			pod("new", "b", k8sapiv1.PodRunning),
+			pod("failed", "a", k8sapiv1.PodFailed),
+		}, "b")
+		Expect(outdated).To(HaveLen(1))
+		Expect(outdated[0].Name).To(Equal("old"))
+		Expect(updated).To(HaveLen(1))
+		Expect(updated[0].Name).To(Equal("new"))
+	})
+
+	DescribeTable("executorUpgradeBatch",
+		func(outdated, active, decommissioning, desired, batchSize, expected int) {
+			Expect(executorUpgradeBatch(outdated, active, decommissioning, desired, batchSize)).To(Equal(expected))
+		},
+		Entry("replaces a batch", 4, 4, 0, 4, 2, 2),
+		Entry("replaces the rest", 1, 4, 0, 4, 2, 1),
+		Entry("waits for replacements", 3, 3, 0, 4, 2, 0),
+		Entry("counts decommissioning executors in the batch", 3, 4, 1, 4, 2, 1),
+		Entry("waits for decommissioning executors", 3, 4, 2, 4, 2, 0),
+	)
+
+	Context("reconcileUpgrade", func() {
+		var ctx context.Context
+		var cluster *v1.BallistaCluster
+		var r *BallistaClusterReconciler
+
+		pods := func(role string) []k8sapiv1.Pod {
+			pods, err := r.listClusterPods(ctx, cluster, role)
+			Expect(err).NotTo(HaveOccurred())
+			return pods
+		}
+		ready := func(role string) {
+			for _, pod := range pods(role) {
+				pod.Status = k8sapiv1.PodStatus{
+					Phase:             k8sapiv1.PodRunning,
+					Conditions:        []k8sapiv1.PodCondition{{Type: k8sapiv1.PodReady, Status: k8sapiv1.ConditionTrue}},
+					ContainerStatuses: []k8sapiv1.ContainerStatus{{Ready: true}},
+				}
+				Expect(r.Status().Update(ctx, &pod)).To(Succeed())
+			}
+		}
+		decommissioning := func() []string {
+			var names []string
+			for _, pod := range pods(executorRole) {
+				if isExecutorDecommissioning(&pod) {
+					names = append(names, pod.Name)
+				}
+			}
+			return names
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			cluster = &v1.BallistaCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default", UID: "cluster-uid"}}
+			cluster.Spec.Scheduler.Containers = []k8sapiv1.Container{{Name: v1.SchedulerContainerName, Image: "ballista:0.5.0"}}
+			cluster.Spec.Executor.Containers = []k8sapiv1.Container{{Name: v1.ExecutorContainerName, Image: "ballista:0.5.0"}}
+			cluster.Spec.Executor.Instances = int32Ptr(2)
+			cluster.Status.SchedulerState = v1.SchedulerRunningState
+
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(v1.AddToScheme(scheme)).To(Succeed())
+			r = &BallistaClusterReconciler{
+				Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster).Build(),
+				Scheme:   scheme,
+				Recorder: record.NewFakeRecorder(50),
+				Clock:    clock.NewFakeClock(time.Now()),
+			}
+
+			Expect(r.createSchedulerPod(ctx, cluster, schedulerPodName(cluster, 0))).To(Succeed())
+			_, err := r.reconcileExecutors(ctx, cluster, r.Clock.Now())
+			Expect(err).NotTo(HaveOccurred())
+			ready(schedulerRole)
+			ready(executorRole)
+		})
+
+		It("decommissions the outdated executors a batch at a time", func() {
+			cluster.Spec.Executor.Containers[0].Image = "ballista:0.6.0"
+			_, err := r.reconcileUpgrade(ctx, cluster, r.Clock.Now())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cluster.Status.Upgrade.Phase).To(Equal(v1.UpgradingExecutorsPhase))
+			Expect(decommissioning()).To(HaveLen(1))
+
+			// the replacement is created by reconcileExecutors, and the next batch waits for the outdated
+			// executor to be gone
+			_, err = r.reconcileExecutors(ctx, cluster, r.Clock.Now())
+			Expect(err).NotTo(HaveOccurred())
+			ready(executorRole)
+			_, err = r.reconcileUpgrade(ctx, cluster, r.Clock.Now())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decommissioning()).To(HaveLen(1))
+		})
+
+		It("halts once a replacement is not ready within the readiness timeout", func() {
+			fakeClock := r.Clock.(*clock.FakeClock)
+			cluster.Spec.Executor.Containers[0].Image = "ballista:0.6.0"
+			_, err := r.reconcileUpgrade(ctx, cluster, fakeClock.Now())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cluster.Status.Upgrade.StartTime.Time).To(Equal(fakeClock.Now()))
+			_, err = r.reconcileExecutors(ctx, cluster, fakeClock.Now())
+			Expect(err).NotTo(HaveOccurred())
+			for _, pod := range pods(executorRole) {
+				if !isPodReady(&pod) {
+					pod.CreationTimestamp = metav1.NewTime(fakeClock.Now())
+					Expect(r.Update(ctx, &pod)).To(Succeed())
+				}
+			}
+
+			fakeClock.Step(readinessTimeout(cluster) - time.Second)
+			requeue, err := r.reconcileUpgrade(ctx, cluster, fakeClock.Now())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requeue).To(Equal(upgradePollInterval))
+			Expect(cluster.Status.Upgrade.Phase).To(Equal(v1.UpgradingExecutorsPhase))
+
+			fakeClock.Step(2 * time.Second)
+			requeue, err = r.reconcileUpgrade(ctx, cluster, fakeClock.Now())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requeue).To(BeZero())
+			Expect(cluster.Status.Upgrade.Phase).To(Equal(v1.UpgradeFailedPhase))
+		})
+	})
+
+	DescribeTable("podReadinessTimedOut",
+		func(age time.Duration, expected bool) {
+			now := time.Now()
+			pod := &k8sapiv1.Pod{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(now.Add(-age))}}
+			Expect(podReadinessTimedOut(pod, time.Minute, now)).To(Equal(expected))
+		},
+		Entry("within the timeout", 30*time.Second, false),
+		Entry("past the timeout", 2*time.Minute, true),
+	)
+
+	DescribeTable("minRequeueInterval",
+		func(a, b, expected time.Duration) {
+			Expect(minRequeueInterval(a, b)).To(Equal(expected))
+		},
+		Entry("no requeue", time.Duration(0), time.Duration(0), time.Duration(0)),
+		Entry("only the first", time.Second, time.Duration(0), time.Second),
+		Entry("only the second", time.Duration(0), time.Second, time.Second),
+		Entry("the shorter", time.Minute, time.Second, time.Second),
+	)
+})
@@ -0,0 +1,336 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	k8sapiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+const (
+	// ballistaQueryFinalizer holds a BallistaQuery back from deletion until its job is cancelled.
+	ballistaQueryFinalizer = "ballista.minzhou.info/query-finalizer"
+	// queryPollInterval is how often a query waiting for its cluster or its job is checked on.
+	queryPollInterval = 10 * time.Second
+	// maxJobStatusFailures is how many times in a row the status of a job may not be fetched before its query
+	// fails, which takes about 5 minutes at queryPollInterval.
+	maxJobStatusFailures = 30
+)
+
+// BallistaQueryReconciler reconciles a BallistaQuery object
+type BallistaQueryReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// QueryClient submits the queries to the schedulers of the clusters.
+	QueryClient QueryClient
+	// Recorder records the events of the queries.
+	Recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=ballista.minzhou.info,resources=ballistaqueries,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=ballista.minzhou.info,resources=ballistaqueries/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=ballista.minzhou.info,resources=ballistaqueries/finalizers,verbs=update
+//+kubebuilder:rbac:groups=ballista.minzhou.info,resources=ballistaclusters,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile submits a query to the scheduler of its cluster once the cluster runs, then follows the job
+// running it until the job succeeds or fails.
+func (r *BallistaQueryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	query := &v1.BallistaQuery{}
+	if err := r.Get(ctx, req.NamespacedName, query); err != nil {
+		log.Error(err, "unable to fetch BallistaQuery")
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !query.DeletionTimestamp.IsZero() {
+		return r.handleBallistaQueryDeletion(ctx, query)
+	}
+	if query.Status.IsFinished() {
+		return ctrl.Result{}, nil
+	}
+	if !controllerutil.ContainsFinalizer(query, ballistaQueryFinalizer) {
+		controllerutil.AddFinalizer(query, ballistaQueryFinalizer)
+		if err := r.Update(ctx, query); err != nil {
+			log.Error(err, "unable to add finalizer to BallistaQuery")
+			return ctrl.Result{}, err
+		}
+	}
+
+	queryCopy := query.DeepCopy()
+	result, err := r.runQuery(ctx, queryCopy)
+	if err != nil {
+		queryCopy.Status.Message = err.Error()
+	}
+	if !equality.Semantic.DeepEqual(query.Status, queryCopy.Status) {
+		if err := r.Status().Update(ctx, queryCopy); err != nil {
+			log.Error(err, "unable to update BallistaQuery status")
+			return ctrl.Result{}, err
+		}
+	}
+
+	if queryCopy.Status.IsFinished() {
+		// a finished query has no job left to cancel
+		controllerutil.RemoveFinalizer(queryCopy, ballistaQueryFinalizer)
+		if err := r.Update(ctx, queryCopy); err != nil {
+			log.Error(err, "unable to remove finalizer from BallistaQuery")
+			return ctrl.Result{}, err
+		}
+	}
+	return result, err
+}
+
+// runQuery submits the query once its cluster runs, and tracks the state of its job afterwards.
+func (r *BallistaQueryReconciler) runQuery(ctx context.Context, query *v1.BallistaQuery) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	// the scheduler may run a query whose submission was interrupted, which must not run twice
+	if query.Status.State == v1.QuerySubmittingState && query.Status.JobID == "" {
+		r.failQuery(query, "the submission of the query was interrupted before its job ID was recorded; "+
+			"the query may still run on the scheduler and is not submitted again")
+		return ctrl.Result{}, nil
+	}
+
+	cluster := &v1.BallistaCluster{}
+	err := r.Get(ctx, types.NamespacedName{Name: query.Spec.ClusterName, Namespace: query.Namespace}, cluster)
+	if apierrors.IsNotFound(err) {
+		if query.Status.JobID != "" {
+			r.failQuery(query, fmt.Sprintf("BallistaCluster %s is gone", query.Spec.ClusterName))
+			return ctrl.Result{}, nil
+		}
+		query.Status.State = v1.QueryPendingState
+		query.Status.Message = fmt.Sprintf("BallistaCluster %s not found", query.Spec.ClusterName)
+		return ctrl.Result{RequeueAfter: queryPollInterval}, nil
+	}
+	if err != nil {
+		log.Error(err, "unable to fetch BallistaCluster of BallistaQuery", "cluster", query.Spec.ClusterName)
+		return ctrl.Result{}, err
+	}
+
+	endpoint, running := clusterEndpoint(cluster)
+	if !running {
+		if query.Status.JobID == "" {
+			query.Status.State = v1.QueryPendingState
+			query.Status.Message = fmt.Sprintf("waiting for BallistaCluster %s to run", cluster.Name)
+		}
+		return ctrl.Result{RequeueAfter: queryPollInterval}, nil
+	}
+
+	if query.Status.JobID == "" {
+		return r.submitQuery(ctx, query, endpoint)
+	}
+	return r.trackQuery(ctx, query, endpoint)
+}
+
+// submitQuery submits the query to the scheduler at the endpoint.
+func (r *BallistaQueryReconciler) submitQuery(ctx context.Context, query *v1.BallistaQuery, endpoint string) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	sql := query.Spec.SQL
+	if ref := query.Spec.SQLFrom; ref != nil {
+		configMap := &k8sapiv1.ConfigMap{}
+		err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: query.Namespace}, configMap)
+		if apierrors.IsNotFound(err) {
+			query.Status.State = v1.QueryPendingState
+			query.Status.Message = fmt.Sprintf("ConfigMap %s not found", ref.Name)
+			return ctrl.Result{RequeueAfter: queryPollInterval}, nil
+		}
+		if err != nil {
+			log.Error(err, "unable to fetch SQL ConfigMap of BallistaQuery", "configMap", ref.Name)
+			return ctrl.Result{}, err
+		}
+		var ok bool
+		if sql, ok = configMap.Data[ref.Key]; !ok || sql == "" {
+			query.Status.State = v1.QueryPendingState
+			query.Status.Message = fmt.Sprintf("ConfigMap %s has no SQL under key %s", ref.Name, ref.Key)
+			return ctrl.Result{RequeueAfter: queryPollInterval}, nil
+		}
+	}
+
+	// the submission is recorded first, so that a query is never submitted twice
+	state := query.Status.State
+	query.Status.State = v1.QuerySubmittingState
+	query.Status.Message = ""
+	if err := r.Status().Update(ctx, query); err != nil {
+		log.Error(err, "unable to record the submission of BallistaQuery")
+		query.Status.State = state
+		return ctrl.Result{}, err
+	}
+
+	jobID, err := r.QueryClient.SubmitQuery(ctx, endpoint, QuerySubmission{
+		SQL:    sql,
+		Tables: query.Spec.Tables,
+		Output: query.Spec.Output,
+	})
+	if err != nil {
+		log.Error(err, "unable to submit BallistaQuery", "endpoint", endpoint)
+		// the scheduler failed the submission, so the query may be submitted again
+		query.Status.State = v1.QueryPendingState
+		return ctrl.Result{}, err
+	}
+	now := metav1.Now()
+	query.Status.JobID = jobID
+	query.Status.State = v1.QueryQueuedState
+	query.Status.SubmissionTime = &now
+	if err := r.recordJob(ctx, query); err != nil {
+		log.Error(err, "unable to record the job of BallistaQuery", "jobID", jobID)
+		return ctrl.Result{}, err
+	}
+	log.Info("submitted query", "jobID", jobID)
+	r.Recorder.Eventf(query, k8sapiv1.EventTypeNormal, eventQuerySubmitted, "Submitted query as job %s", jobID)
+	return ctrl.Result{RequeueAfter: queryPollInterval}, nil
+}
+
+// recordJob records the status of a query just submitted, retrying on conflicts as the job would otherwise
+// be lost.
+func (r *BallistaQueryReconciler) recordJob(ctx context.Context, query *v1.BallistaQuery) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &v1.BallistaQuery{}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(query), latest); err != nil {
+			return err
+		}
+		latest.Status = query.Status
+		if err := r.Status().Update(ctx, latest); err != nil {
+			return err
+		}
+		query.ResourceVersion = latest.ResourceVersion
+		return nil
+	})
+}
+
+// trackQuery updates the state of the query from the status of its job on the scheduler at the endpoint. The
+// query fails once the scheduler no longer knows the job, or could not tell its status maxJobStatusFailures
+// times in a row.
+func (r *BallistaQueryReconciler) trackQuery(ctx context.Context, query *v1.BallistaQuery, endpoint string) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	status, err := r.QueryClient.JobStatus(ctx, endpoint, query.Status.JobID)
+	if errors.Is(err, errJobNotFound) {
+		r.failQuery(query, fmt.Sprintf("job %s lost: the scheduler no longer knows it", query.Status.JobID))
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		log.Error(err, "unable to fetch job status of BallistaQuery", "jobID", query.Status.JobID)
+		query.Status.JobStatusFailures++
+		if query.Status.JobStatusFailures >= maxJobStatusFailures {
+			r.failQuery(query, fmt.Sprintf("job %s lost: its status could not be fetched %d times in a row: %v",
+				query.Status.JobID, query.Status.JobStatusFailures, err))
+			return ctrl.Result{}, nil
+		}
+		query.Status.Message = fmt.Sprintf("unable to fetch the status of job %s: %v", query.Status.JobID, err)
+		return ctrl.Result{RequeueAfter: queryPollInterval}, nil
+	}
+	query.Status.JobStatusFailures = 0
+	switch status.Status {
+	case "Queued":
+		query.Status.State = v1.QueryQueuedState
+	case "Running":
+		query.Status.State = v1.QueryRunningState
+	case "Completed":
+		now := metav1.Now()
+		query.Status.State = v1.QuerySucceededState
+		query.Status.Message = ""
+		query.Status.CompletionTime = &now
+		r.Recorder.Eventf(query, k8sapiv1.EventTypeNormal, eventQuerySucceeded, "Job %s completed", query.Status.JobID)
+		return ctrl.Result{}, nil
+	case "Failed":
+		r.failQuery(query, fmt.Sprintf("job %s failed: %s", query.Status.JobID, status.Error))
+		return ctrl.Result{}, nil
+	default:
+		log.Info("unknown job status", "jobID", query.Status.JobID, "status", status.Status)
+	}
+	query.Status.Message = ""
+	return ctrl.Result{RequeueAfter: queryPollInterval}, nil
+}
+
+// failQuery marks the query as failed with the message.
+func (r *BallistaQueryReconciler) failQuery(query *v1.BallistaQuery, message string) {
+	now := metav1.Now()
+	query.Status.State = v1.QueryFailedState
+	query.Status.Message = message
+	query.Status.CompletionTime = &now
+	r.Recorder.Event(query, k8sapiv1.EventTypeWarning, eventQueryFailed, message)
+}
+
+// handleBallistaQueryDeletion cancels the job of a query that has not finished, and only then releases the
+// finalizer. The job is gone already when its cluster no longer runs.
+func (r *BallistaQueryReconciler) handleBallistaQueryDeletion(ctx context.Context, query *v1.BallistaQuery) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	if !controllerutil.ContainsFinalizer(query, ballistaQueryFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	if query.Status.JobID != "" && !query.Status.IsFinished() {
+		cluster := &v1.BallistaCluster{}
+		err := r.Get(ctx, types.NamespacedName{Name: query.Spec.ClusterName, Namespace: query.Namespace}, cluster)
+		if err != nil && !apierrors.IsNotFound(err) {
+			log.Error(err, "unable to fetch BallistaCluster of BallistaQuery", "cluster", query.Spec.ClusterName)
+			return ctrl.Result{}, err
+		}
+		if endpoint, running := clusterEndpoint(cluster); err == nil && running {
+			if err := r.QueryClient.CancelJob(ctx, endpoint, query.Status.JobID); err != nil {
+				log.Error(err, "unable to cancel job of BallistaQuery", "jobID", query.Status.JobID)
+				return ctrl.Result{}, err
+			}
+			log.Info("cancelled job", "jobID", query.Status.JobID)
+			r.Recorder.Eventf(query, k8sapiv1.EventTypeNormal, eventQueryCancelled, "Cancelled job %s", query.Status.JobID)
+		}
+	}
+
+	controllerutil.RemoveFinalizer(query, ballistaQueryFinalizer)
+	if err := r.Update(ctx, query); err != nil {
+		log.Error(err, "unable to remove finalizer from BallistaQuery")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// clusterEndpoint returns the scheduler endpoint of the cluster, and whether the cluster runs and takes
+// queries there.
+func clusterEndpoint(cluster *v1.BallistaCluster) (string, bool) {
+	status := cluster.Status
+	running := status.ClusterState.State == v1.RunningState &&
+		status.SchedulerState == v1.SchedulerRunningState &&
+		status.SchedulerEndpoint != ""
+	return status.SchedulerEndpoint, running
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *BallistaQueryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1.BallistaQuery{}).
+		Complete(r)
+}
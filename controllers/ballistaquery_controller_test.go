@@ -0,0 +1,241 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	k8sapiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+var _ = Describe("BallistaQuery controller", func() {
+	var (
+		ctx         context.Context
+		cluster     *v1.BallistaCluster
+		query       *v1.BallistaQuery
+		queryClient *fakeQueryClient
+		recorder    *record.FakeRecorder
+		request     ctrl.Request
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		cluster = &v1.BallistaCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"}}
+		cluster.Status.ClusterState.State = v1.RunningState
+		cluster.Status.SchedulerState = v1.SchedulerRunningState
+		cluster.Status.SchedulerEndpoint = "cluster-scheduler.default.svc:50050"
+		query = &v1.BallistaQuery{
+			ObjectMeta: metav1.ObjectMeta{Name: "query", Namespace: "default"},
+			Spec:       v1.BallistaQuerySpec{ClusterName: "cluster", SQL: "SELECT 1"},
+		}
+		queryClient = &fakeQueryClient{jobID: "j1"}
+		recorder = record.NewFakeRecorder(10)
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "query", Namespace: "default"}}
+	})
+
+	reconciler := func(objects ...client.Object) *BallistaQueryReconciler {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(v1.AddToScheme(scheme)).To(Succeed())
+		return &BallistaQueryReconciler{
+			Client:      fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+			Scheme:      scheme,
+			QueryClient: queryClient,
+			Recorder:    recorder,
+		}
+	}
+
+	fetch := func(r *BallistaQueryReconciler) *v1.BallistaQuery {
+		fetched := &v1.BallistaQuery{}
+		Expect(r.Get(ctx, request.NamespacedName, fetched)).To(Succeed())
+		return fetched
+	}
+
+	It("waits for the cluster to run", func() {
+		cluster.Status.ClusterState.State = v1.NewState
+		r := reconciler(cluster, query)
+		result, err := r.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(queryPollInterval))
+		Expect(queryClient.submitted).To(BeEmpty())
+		Expect(fetch(r).Status.State).To(Equal(v1.QueryPendingState))
+	})
+
+	It("submits the query to the scheduler", func() {
+		r := reconciler(cluster, query)
+		_, err := r.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(queryClient.submitted).To(Equal([]QuerySubmission{{SQL: "SELECT 1"}}))
+
+		fetched := fetch(r)
+		Expect(fetched.Status.State).To(Equal(v1.QueryQueuedState))
+		Expect(fetched.Status.JobID).To(Equal("j1"))
+		Expect(fetched.Status.SubmissionTime).NotTo(BeNil())
+		Expect(fetched.Finalizers).To(ContainElement(ballistaQueryFinalizer))
+		Expect(recorder.Events).To(Receive(ContainSubstring(eventQuerySubmitted)))
+	})
+
+	It("reads the SQL from a ConfigMap", func() {
+		query.Spec.SQL = ""
+		query.Spec.SQLFrom = &k8sapiv1.ConfigMapKeySelector{
+			LocalObjectReference: k8sapiv1.LocalObjectReference{Name: "queries"},
+			Key:                  "fares.sql",
+		}
+		configMap := &k8sapiv1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "queries", Namespace: "default"},
+			Data:       map[string]string{"fares.sql": "SELECT 2"},
+		}
+		r := reconciler(cluster, query, configMap)
+		_, err := r.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(queryClient.submitted).To(Equal([]QuerySubmission{{SQL: "SELECT 2"}}))
+	})
+
+	It("records the submission before submitting the query", func() {
+		r := reconciler(cluster, query)
+		queryClient.onSubmit = func() {
+			Expect(fetch(r).Status.State).To(Equal(v1.QuerySubmittingState))
+		}
+		_, err := r.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(queryClient.submitted).To(HaveLen(1))
+		Expect(fetch(r).Status.JobID).To(Equal("j1"))
+	})
+
+	It("submits the query again when the scheduler failed the submission", func() {
+		queryClient.err = errors.New("unavailable")
+		r := reconciler(cluster, query)
+		_, err := r.Reconcile(ctx, request)
+		Expect(err).To(HaveOccurred())
+		Expect(fetch(r).Status.State).To(Equal(v1.QueryPendingState))
+
+		queryClient.err = nil
+		_, err = r.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(queryClient.submitted).To(HaveLen(2))
+		Expect(fetch(r).Status.JobID).To(Equal("j1"))
+	})
+
+	It("does not submit a query again whose submission was interrupted", func() {
+		query.Status.State = v1.QuerySubmittingState
+		r := reconciler(cluster, query)
+		_, err := r.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(queryClient.submitted).To(BeEmpty())
+		Expect(fetch(r).Status.State).To(Equal(v1.QueryFailedState))
+	})
+
+	It("follows the job to its completion", func() {
+		query.Status = v1.BallistaQueryStatus{State: v1.QueryQueuedState, JobID: "j1"}
+		r := reconciler(cluster, query)
+
+		queryClient.status = JobStatus{Status: "Running"}
+		_, err := r.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fetch(r).Status.State).To(Equal(v1.QueryRunningState))
+
+		queryClient.status = JobStatus{Status: "Completed"}
+		result, err := r.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeZero())
+		fetched := fetch(r)
+		Expect(fetched.Status.State).To(Equal(v1.QuerySucceededState))
+		Expect(fetched.Status.CompletionTime).NotTo(BeNil())
+		Expect(fetched.Finalizers).NotTo(ContainElement(ballistaQueryFinalizer))
+	})
+
+	It("reports the error of a failed job", func() {
+		query.Status = v1.BallistaQueryStatus{State: v1.QueryRunningState, JobID: "j1"}
+		queryClient.status = JobStatus{Status: "Failed", Error: "table not found"}
+		r := reconciler(cluster, query)
+		_, err := r.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		fetched := fetch(r)
+		Expect(fetched.Status.State).To(Equal(v1.QueryFailedState))
+		Expect(fetched.Status.Message).To(Equal("job j1 failed: table not found"))
+		Expect(recorder.Events).To(Receive(ContainSubstring(eventQueryFailed)))
+	})
+
+	It("fails the query of a job the scheduler lost", func() {
+		query.Status = v1.BallistaQueryStatus{State: v1.QueryRunningState, JobID: "j1"}
+		queryClient.err = errJobNotFound
+		r := reconciler(cluster, query)
+		_, err := r.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		fetched := fetch(r)
+		Expect(fetched.Status.State).To(Equal(v1.QueryFailedState))
+		Expect(fetched.Status.Message).To(HavePrefix("job j1 lost"))
+		Expect(recorder.Events).To(Receive(ContainSubstring(eventQueryFailed)))
+	})
+
+	It("fails the query once the status of its job cannot be fetched too many times in a row", func() {
+		query.Status = v1.BallistaQueryStatus{State: v1.QueryRunningState, JobID: "j1"}
+		r := reconciler(cluster, query)
+
+		queryClient.err = errors.New("unavailable")
+		for i := 1; i < maxJobStatusFailures; i++ {
+			result, err := r.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(queryPollInterval))
+		}
+		fetched := fetch(r)
+		Expect(fetched.Status.State).To(Equal(v1.QueryRunningState))
+		Expect(fetched.Status.JobStatusFailures).To(Equal(int32(maxJobStatusFailures - 1)))
+
+		// a status fetched in between starts the count again
+		queryClient.err = nil
+		queryClient.status = JobStatus{Status: "Running"}
+		_, err := r.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fetch(r).Status.JobStatusFailures).To(BeZero())
+
+		queryClient.err = errors.New("unavailable")
+		for i := 0; i < maxJobStatusFailures; i++ {
+			_, err = r.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		fetched = fetch(r)
+		Expect(fetched.Status.State).To(Equal(v1.QueryFailedState))
+		Expect(fetched.Status.Message).To(HavePrefix("job j1 lost"))
+		Expect(fetched.Finalizers).NotTo(ContainElement(ballistaQueryFinalizer))
+	})
+
+	It("cancels the job of a deleted query", func() {
+		now := metav1.Now()
+		query.DeletionTimestamp = &now
+		query.Finalizers = []string{ballistaQueryFinalizer}
+		query.Status = v1.BallistaQueryStatus{State: v1.QueryRunningState, JobID: "j1"}
+		r := reconciler(cluster, query)
+		_, err := r.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(queryClient.cancelled).To(Equal([]string{"j1"}))
+		Expect(fetch(r).Finalizers).To(BeEmpty())
+	})
+})
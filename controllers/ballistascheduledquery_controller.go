@@ -0,0 +1,328 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	k8sapiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/record"
+	ref "k8s.io/client-go/tools/reference"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+const (
+	// scheduledQueryNameKey labels the runs of a scheduled query with its name.
+	scheduledQueryNameKey = "ballista-scheduled-query"
+	// scheduledTimeAnnotation records on a run the time it was scheduled for.
+	scheduledTimeAnnotation = "ballista.minzhou.info/scheduled-at"
+	// maxMissedRuns bounds the number of missed schedule times looked at, beyond which the schedule is deemed
+	// broken, like that of a query suspended for long without a starting deadline.
+	maxMissedRuns = 100
+)
+
+// BallistaScheduledQueryReconciler reconciles a BallistaScheduledQuery object
+type BallistaScheduledQueryReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// Recorder records the events of the scheduled queries.
+	Recorder record.EventRecorder
+	// Clock tells the time the schedules are evaluated at.
+	Clock clock.Clock
+}
+
+//+kubebuilder:rbac:groups=ballista.minzhou.info,resources=ballistascheduledqueries,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=ballista.minzhou.info,resources=ballistascheduledqueries/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=ballista.minzhou.info,resources=ballistascheduledqueries/finalizers,verbs=update
+//+kubebuilder:rbac:groups=ballista.minzhou.info,resources=ballistaqueries,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile creates the BallistaQuery of a scheduled query whenever its schedule is due, keeps track of the
+// runs in progress, and prunes the history of the finished ones.
+func (r *BallistaScheduledQueryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	scheduledQuery := &v1.BallistaScheduledQuery{}
+	if err := r.Get(ctx, req.NamespacedName, scheduledQuery); err != nil {
+		log.Error(err, "unable to fetch BallistaScheduledQuery")
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	runs, err := r.listRuns(ctx, scheduledQuery)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	scheduledQueryCopy := scheduledQuery.DeepCopy()
+	active, successful, failed := classifyRuns(runs)
+	if err := r.setRunStatus(scheduledQueryCopy, runs, active); err != nil {
+		log.Error(err, "unable to reference active runs of BallistaScheduledQuery")
+		return ctrl.Result{}, err
+	}
+	r.pruneRuns(ctx, successful, scheduledQuery.Spec.SuccessfulRunsHistoryLimit)
+	r.pruneRuns(ctx, failed, scheduledQuery.Spec.FailedRunsHistoryLimit)
+
+	result, err := r.scheduleRun(ctx, scheduledQueryCopy, active)
+
+	if !equality.Semantic.DeepEqual(scheduledQuery.Status, scheduledQueryCopy.Status) {
+		if err := r.Status().Update(ctx, scheduledQueryCopy); err != nil {
+			log.Error(err, "unable to update BallistaScheduledQuery status")
+			return ctrl.Result{}, err
+		}
+	}
+	return result, err
+}
+
+// listRuns returns the runs of the scheduled query, sorted by name.
+func (r *BallistaScheduledQueryReconciler) listRuns(ctx context.Context, scheduledQuery *v1.BallistaScheduledQuery) ([]*v1.BallistaQuery, error) {
+	log := log.FromContext(ctx)
+
+	var queries v1.BallistaQueryList
+	if err := r.List(ctx, &queries, client.InNamespace(scheduledQuery.Namespace),
+		client.MatchingLabels{scheduledQueryNameKey: scheduledQuery.Name}); err != nil {
+		log.Error(err, "unable to list runs of BallistaScheduledQuery")
+		return nil, err
+	}
+	var runs []*v1.BallistaQuery
+	for i := range queries.Items {
+		if metav1.IsControlledBy(&queries.Items[i], scheduledQuery) {
+			runs = append(runs, &queries.Items[i])
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Name < runs[j].Name })
+	return runs, nil
+}
+
+// classifyRuns splits the runs into the ones in progress, the successful ones and the failed ones.
+func classifyRuns(runs []*v1.BallistaQuery) (active, successful, failed []*v1.BallistaQuery) {
+	for _, run := range runs {
+		switch run.Status.State {
+		case v1.QuerySucceededState:
+			successful = append(successful, run)
+		case v1.QueryFailedState:
+			failed = append(failed, run)
+		default:
+			active = append(active, run)
+		}
+	}
+	return active, successful, failed
+}
+
+// setRunStatus records the runs in progress and the times of the latest runs in the status of the scheduled
+// query. The times only move forward, as the runs they come from may be pruned since.
+func (r *BallistaScheduledQueryReconciler) setRunStatus(scheduledQuery *v1.BallistaScheduledQuery, runs, active []*v1.BallistaQuery) error {
+	status := &scheduledQuery.Status
+	status.Active = nil
+	for _, run := range active {
+		reference, err := ref.GetReference(r.Scheme, run)
+		if err != nil {
+			return err
+		}
+		status.Active = append(status.Active, *reference)
+	}
+
+	for _, run := range runs {
+		if scheduledTime, ok := runScheduledTime(run); ok && (status.LastScheduleTime == nil || status.LastScheduleTime.Before(scheduledTime)) {
+			status.LastScheduleTime = scheduledTime
+		}
+		completionTime := run.Status.CompletionTime
+		if run.Status.State == v1.QuerySucceededState && completionTime != nil &&
+			(status.LastSuccessfulTime == nil || status.LastSuccessfulTime.Before(completionTime)) {
+			status.LastSuccessfulTime = completionTime.DeepCopy()
+		}
+	}
+	return nil
+}
+
+// pruneRuns deletes the oldest of the finished runs beyond the history limit, if any. Failing to delete a
+// run is not fatal, it is tried again on the next reconcile.
+func (r *BallistaScheduledQueryReconciler) pruneRuns(ctx context.Context, finished []*v1.BallistaQuery, limit *int32) {
+	log := log.FromContext(ctx)
+
+	if limit == nil || len(finished) <= int(*limit) {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return runFinishedTime(finished[i]).Before(runFinishedTime(finished[j]))
+	})
+	for _, run := range finished[:len(finished)-int(*limit)] {
+		if err := r.Delete(ctx, run, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			log.Error(err, "unable to delete old run of BallistaScheduledQuery", "query", run.Name)
+			continue
+		}
+		log.Info("deleted old run", "query", run.Name)
+	}
+}
+
+// scheduleRun creates the run the schedule is due for, if any, as the concurrency policy allows, and requeues
+// the scheduled query for the next schedule time.
+func (r *BallistaScheduledQueryReconciler) scheduleRun(ctx context.Context, scheduledQuery *v1.BallistaScheduledQuery, active []*v1.BallistaQuery) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	if scheduledQuery.Spec.Suspend != nil && *scheduledQuery.Spec.Suspend {
+		return ctrl.Result{}, nil
+	}
+
+	now := r.Clock.Now()
+	missedRun, nextRun, err := nextScheduleTimes(scheduledQuery, now)
+	if err != nil {
+		// the schedule won't fix itself, there is no point in retrying before the spec changes
+		log.Error(err, "unable to evaluate schedule of BallistaScheduledQuery", "schedule", scheduledQuery.Spec.Schedule)
+		r.Recorder.Event(scheduledQuery, k8sapiv1.EventTypeWarning, eventInvalidSchedule, err.Error())
+		return ctrl.Result{}, nil
+	}
+	result := ctrl.Result{RequeueAfter: nextRun.Sub(now)}
+	if missedRun.IsZero() {
+		return result, nil
+	}
+
+	if deadline := scheduledQuery.Spec.StartingDeadlineSeconds; deadline != nil &&
+		missedRun.Add(time.Duration(*deadline)*time.Second).Before(now) {
+		r.Recorder.Eventf(scheduledQuery, k8sapiv1.EventTypeWarning, eventMissedSchedule,
+			"Missed the starting deadline of the run scheduled at %s", missedRun.Format(time.RFC3339))
+		return result, nil
+	}
+
+	switch scheduledQuery.Spec.ConcurrencyPolicy {
+	case v1.ForbidConcurrent:
+		if len(active) > 0 {
+			r.Recorder.Eventf(scheduledQuery, k8sapiv1.EventTypeNormal, eventRunSkipped,
+				"Skipped the run scheduled at %s as %d runs have not finished", missedRun.Format(time.RFC3339), len(active))
+			return result, nil
+		}
+	case v1.ReplaceConcurrent:
+		for _, run := range active {
+			// the finalizer of the query cancels its job
+			if err := r.Delete(ctx, run, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+				log.Error(err, "unable to delete active run of BallistaScheduledQuery", "query", run.Name)
+				return ctrl.Result{}, err
+			}
+			r.Recorder.Eventf(scheduledQuery, k8sapiv1.EventTypeNormal, eventRunReplaced, "Deleted run %s to replace it", run.Name)
+		}
+	}
+
+	run, err := r.newRun(scheduledQuery, missedRun)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.Create(ctx, run); err != nil && !apierrors.IsAlreadyExists(err) {
+		log.Error(err, "unable to create run of BallistaScheduledQuery", "query", run.Name)
+		return ctrl.Result{}, err
+	}
+	log.Info("created run", "query", run.Name)
+	r.Recorder.Eventf(scheduledQuery, k8sapiv1.EventTypeNormal, eventRunCreated, "Created run %s", run.Name)
+
+	scheduledTime := metav1.NewTime(missedRun)
+	scheduledQuery.Status.LastScheduleTime = &scheduledTime
+	return result, nil
+}
+
+// newRun builds the BallistaQuery of the run of the scheduled query scheduled at the given time. The name of
+// the run is derived from that time, so that a run is never created twice.
+func (r *BallistaScheduledQueryReconciler) newRun(scheduledQuery *v1.BallistaScheduledQuery, scheduledTime time.Time) (*v1.BallistaQuery, error) {
+	run := &v1.BallistaQuery{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("%s-%d", scheduledQuery.Name, scheduledTime.Unix()),
+			Namespace:   scheduledQuery.Namespace,
+			Labels:      map[string]string{scheduledQueryNameKey: scheduledQuery.Name},
+			Annotations: map[string]string{scheduledTimeAnnotation: scheduledTime.Format(time.RFC3339)},
+		},
+		Spec: *scheduledQuery.Spec.Query.DeepCopy(),
+	}
+	if err := ctrl.SetControllerReference(scheduledQuery, run, r.Scheme); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// nextScheduleTimes returns the latest schedule time of the scheduled query that passed without a run, if
+// any, and the next schedule time. Schedule times older than the starting deadline are not looked at.
+func nextScheduleTimes(scheduledQuery *v1.BallistaScheduledQuery, now time.Time) (missed time.Time, next time.Time, err error) {
+	schedule, err := cron.ParseStandard(scheduledQuery.Spec.Schedule)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("unparseable schedule %q: %v", scheduledQuery.Spec.Schedule, err)
+	}
+
+	earliest := scheduledQuery.CreationTimestamp.Time
+	if scheduledQuery.Status.LastScheduleTime != nil {
+		earliest = scheduledQuery.Status.LastScheduleTime.Time
+	}
+	if deadline := scheduledQuery.Spec.StartingDeadlineSeconds; deadline != nil {
+		if startingDeadline := now.Add(-time.Duration(*deadline) * time.Second); startingDeadline.After(earliest) {
+			earliest = startingDeadline
+		}
+	}
+	if earliest.After(now) {
+		return time.Time{}, schedule.Next(now), nil
+	}
+
+	missedRuns := 0
+	for t := schedule.Next(earliest); !t.After(now); t = schedule.Next(t) {
+		missed = t
+		missedRuns++
+		if missedRuns > maxMissedRuns {
+			return time.Time{}, time.Time{}, fmt.Errorf("more than %d missed schedule times, set or decrease startingDeadlineSeconds", maxMissedRuns)
+		}
+	}
+	return missed, schedule.Next(now), nil
+}
+
+// runScheduledTime returns the time the run was scheduled for, as recorded on the run.
+func runScheduledTime(run *v1.BallistaQuery) (*metav1.Time, bool) {
+	value, ok := run.Annotations[scheduledTimeAnnotation]
+	if !ok {
+		return nil, false
+	}
+	scheduledTime, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, false
+	}
+	t := metav1.NewTime(scheduledTime)
+	return &t, true
+}
+
+// runFinishedTime returns the time the run finished, or was created if it does not tell.
+func runFinishedTime(run *v1.BallistaQuery) time.Time {
+	if run.Status.CompletionTime != nil {
+		return run.Status.CompletionTime.Time
+	}
+	return run.CreationTimestamp.Time
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *BallistaScheduledQueryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Clock == nil {
+		r.Clock = clock.RealClock{}
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1.BallistaScheduledQuery{}).
+		Owns(&v1.BallistaQuery{}).
+		Complete(r)
+}
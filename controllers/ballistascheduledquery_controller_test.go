@@ -0,0 +1,220 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+)
+
+var _ = Describe("BallistaScheduledQuery controller", func() {
+	var (
+		ctx            context.Context
+		created        time.Time
+		scheduledQuery *v1.BallistaScheduledQuery
+		fakeClock      *clock.FakeClock
+		recorder       *record.FakeRecorder
+		scheme         *runtime.Scheme
+		request        ctrl.Request
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		created = time.Date(2021, 6, 1, 1, 30, 0, 0, time.UTC)
+		scheduledQuery = &v1.BallistaScheduledQuery{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "nightly",
+				Namespace:         "default",
+				UID:               "nightly-uid",
+				CreationTimestamp: metav1.NewTime(created),
+			},
+			Spec: v1.BallistaScheduledQuerySpec{
+				Schedule: "0 2 * * *",
+				Query:    v1.BallistaQuerySpec{ClusterName: "cluster", SQL: "SELECT 1"},
+			},
+		}
+		fakeClock = clock.NewFakeClock(created)
+		recorder = record.NewFakeRecorder(10)
+		scheme = runtime.NewScheme()
+		Expect(v1.AddToScheme(scheme)).To(Succeed())
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "nightly", Namespace: "default"}}
+	})
+
+	reconciler := func(objects ...client.Object) *BallistaScheduledQueryReconciler {
+		return &BallistaScheduledQueryReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+			Scheme:   scheme,
+			Recorder: recorder,
+			Clock:    fakeClock,
+		}
+	}
+
+	runs := func(r *BallistaScheduledQueryReconciler) []v1.BallistaQuery {
+		var queries v1.BallistaQueryList
+		Expect(r.List(ctx, &queries)).To(Succeed())
+		return queries.Items
+	}
+
+	// run builds a run of the scheduled query scheduled at the given time, in the given state.
+	run := func(r *BallistaScheduledQueryReconciler, scheduledTime time.Time, state v1.QueryState) *v1.BallistaQuery {
+		query, err := r.newRun(scheduledQuery, scheduledTime)
+		Expect(err).NotTo(HaveOccurred())
+		query.Status.State = state
+		if state == v1.QuerySucceededState || state == v1.QueryFailedState {
+			completionTime := metav1.NewTime(scheduledTime.Add(time.Minute))
+			query.Status.CompletionTime = &completionTime
+		}
+		return query
+	}
+
+	Describe("nextScheduleTimes", func() {
+		It("has missed nothing before the first schedule time", func() {
+			missed, next, err := nextScheduleTimes(scheduledQuery, created.Add(10*time.Minute))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(missed.IsZero()).To(BeTrue())
+			Expect(next).To(Equal(time.Date(2021, 6, 1, 2, 0, 0, 0, time.UTC)))
+		})
+
+		It("returns the latest missed schedule time", func() {
+			missed, next, err := nextScheduleTimes(scheduledQuery, created.Add(49*time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(missed).To(Equal(time.Date(2021, 6, 3, 2, 0, 0, 0, time.UTC)))
+			Expect(next).To(Equal(time.Date(2021, 6, 4, 2, 0, 0, 0, time.UTC)))
+		})
+
+		It("looks no further back than the starting deadline", func() {
+			deadline := int64(600)
+			scheduledQuery.Spec.StartingDeadlineSeconds = &deadline
+			missed, _, err := nextScheduleTimes(scheduledQuery, created.Add(time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(missed.IsZero()).To(BeTrue())
+		})
+
+		It("gives up on too many missed schedule times", func() {
+			scheduledQuery.Spec.Schedule = "* * * * *"
+			_, _, err := nextScheduleTimes(scheduledQuery, created.Add(3*time.Hour))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("rejects an invalid schedule", func() {
+			scheduledQuery.Spec.Schedule = "every night"
+			_, _, err := nextScheduleTimes(scheduledQuery, created)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	It("waits for the schedule time", func() {
+		r := reconciler(scheduledQuery)
+		result, err := r.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(30 * time.Minute))
+		Expect(runs(r)).To(BeEmpty())
+	})
+
+	It("creates a run when the schedule is due", func() {
+		r := reconciler(scheduledQuery)
+		fakeClock.SetTime(created.Add(31 * time.Minute))
+		result, err := r.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(24*time.Hour - time.Minute))
+
+		queries := runs(r)
+		Expect(queries).To(HaveLen(1))
+		Expect(queries[0].Spec).To(Equal(scheduledQuery.Spec.Query))
+		Expect(metav1.IsControlledBy(&queries[0], scheduledQuery)).To(BeTrue())
+		Expect(recorder.Events).To(Receive(ContainSubstring(eventRunCreated)))
+
+		fetched := &v1.BallistaScheduledQuery{}
+		Expect(r.Get(ctx, request.NamespacedName, fetched)).To(Succeed())
+		Expect(fetched.Status.LastScheduleTime.UTC()).To(Equal(time.Date(2021, 6, 1, 2, 0, 0, 0, time.UTC)))
+	})
+
+	It("creates nothing while suspended", func() {
+		suspend := true
+		scheduledQuery.Spec.Suspend = &suspend
+		r := reconciler(scheduledQuery)
+		fakeClock.SetTime(created.Add(31 * time.Minute))
+		_, err := r.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runs(r)).To(BeEmpty())
+	})
+
+	It("skips a run while another one is active under the Forbid policy", func() {
+		scheduledQuery.Spec.ConcurrencyPolicy = v1.ForbidConcurrent
+		r := reconciler(scheduledQuery)
+		Expect(r.Create(ctx, run(r, created.Add(-time.Hour), v1.QueryRunningState))).To(Succeed())
+		fakeClock.SetTime(created.Add(31 * time.Minute))
+
+		_, err := r.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runs(r)).To(HaveLen(1))
+		Expect(recorder.Events).To(Receive(ContainSubstring(eventRunSkipped)))
+
+		fetched := &v1.BallistaScheduledQuery{}
+		Expect(r.Get(ctx, request.NamespacedName, fetched)).To(Succeed())
+		Expect(fetched.Status.Active).To(HaveLen(1))
+	})
+
+	It("replaces the active run under the Replace policy", func() {
+		scheduledQuery.Spec.ConcurrencyPolicy = v1.ReplaceConcurrent
+		r := reconciler(scheduledQuery)
+		Expect(r.Create(ctx, run(r, created.Add(-time.Hour), v1.QueryRunningState))).To(Succeed())
+		fakeClock.SetTime(created.Add(31 * time.Minute))
+
+		_, err := r.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		remaining := runs(r)
+		Expect(remaining).To(HaveLen(1))
+		Expect(remaining[0].Annotations[scheduledTimeAnnotation]).To(Equal("2021-06-01T02:00:00Z"))
+	})
+
+	It("prunes the history of finished runs", func() {
+		limit := int32(1)
+		scheduledQuery.Spec.SuccessfulRunsHistoryLimit = &limit
+		scheduledQuery.Spec.FailedRunsHistoryLimit = &limit
+		r := reconciler(scheduledQuery)
+		for day := 28; day <= 30; day++ {
+			Expect(r.Create(ctx, run(r, time.Date(2021, 5, day, 2, 0, 0, 0, time.UTC), v1.QuerySucceededState))).To(Succeed())
+		}
+		Expect(r.Create(ctx, run(r, time.Date(2021, 5, 27, 2, 0, 0, 0, time.UTC), v1.QueryFailedState))).To(Succeed())
+		Expect(r.Create(ctx, run(r, time.Date(2021, 5, 31, 2, 0, 0, 0, time.UTC), v1.QueryFailedState))).To(Succeed())
+
+		_, err := r.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		remaining := runs(r)
+		Expect(remaining).To(HaveLen(2))
+		Expect(remaining[0].Annotations[scheduledTimeAnnotation]).To(Equal("2021-05-30T02:00:00Z"))
+		Expect(remaining[1].Annotations[scheduledTimeAnnotation]).To(Equal("2021-05-31T02:00:00Z"))
+
+		fetched := &v1.BallistaScheduledQuery{}
+		Expect(r.Get(ctx, request.NamespacedName, fetched)).To(Succeed())
+		Expect(fetched.Status.LastSuccessfulTime.UTC()).To(Equal(time.Date(2021, 5, 30, 2, 1, 0, 0, time.UTC)))
+	})
+})
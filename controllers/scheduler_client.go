@@ -0,0 +1,374 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+	"github.com/coderplay/ballista-operator/pkg/ballista"
+)
+
+// SchedulerMetricsClient reads the job and task metrics of the scheduler of a Ballista cluster.
+type SchedulerMetricsClient interface {
+	// Metrics returns the job and task metrics of the scheduler.
+	Metrics(ctx context.Context, endpoint string) (SchedulerMetrics, error)
+}
+
+// SchedulerMetrics are the job and task counts of a scheduler.
+type SchedulerMetrics struct {
+	// PendingTasks is the number of tasks waiting for an executor.
+	PendingTasks int
+	// ActiveJobs is the number of jobs submitted that have not completed, failed or been cancelled yet.
+	ActiveJobs int
+}
+
+// SchedulerClient queries the scheduler of a Ballista cluster. Endpoints are the host:port of the scheduler
+// service, as published in Status.SchedulerEndpoint.
+type SchedulerClient interface {
+	SchedulerMetricsClient
+	// ActiveJobs returns the number of jobs queued or running on the scheduler.
+	ActiveJobs(ctx context.Context, endpoint string) (int, error)
+	// Executors returns the executors registered with the scheduler.
+	Executors(ctx context.Context, endpoint string) ([]SchedulerExecutor, error)
+	// StopExecutor tells the scheduler the executor with the given ID is stopping, so that it no longer
+	// assigns it tasks.
+	StopExecutor(ctx context.Context, endpoint string, executorID string) error
+}
+
+// SchedulerExecutor is an executor as registered with the scheduler.
+type SchedulerExecutor struct {
+	// ID is the ID the executor registered with.
+	ID string
+	// Host is the host the executor serves Arrow Flight on, which is the IP of its pod.
+	Host string
+	// Port is the port the executor serves Arrow Flight on.
+	Port int32
+}
+
+// QueryClient submits queries to the scheduler of a Ballista cluster and follows the jobs running them.
+type QueryClient interface {
+	// SubmitQuery submits the query and returns the ID of the job running it.
+	SubmitQuery(ctx context.Context, endpoint string, query QuerySubmission) (string, error)
+	// JobStatus returns the status of the job with the given ID, or errJobNotFound when the scheduler does not
+	// know the job.
+	JobStatus(ctx context.Context, endpoint string, jobID string) (JobStatus, error)
+	// CancelJob cancels the job with the given ID.
+	CancelJob(ctx context.Context, endpoint string, jobID string) error
+}
+
+// QuerySubmission is a query as submitted to the scheduler.
+type QuerySubmission struct {
+	// SQL is the SQL text of the query.
+	SQL string
+	// Tables are registered with the session of the query before it runs.
+	Tables []v1.QueryTable
+	// Output is where the results of the query are written.
+	Output *v1.QueryOutput
+}
+
+// JobStatus is the status of a job as reported by the scheduler.
+type JobStatus struct {
+	// Status is one of Queued, Running, Completed and Failed.
+	Status string
+	// Error is the error a failed job failed with.
+	Error string
+}
+
+// errJobNotFound tells that the scheduler does not know a job, which was lost with the state of the scheduler.
+var errJobNotFound = errors.New("job not found")
+
+const (
+	// schedulerRequestTimeout bounds every request to a scheduler.
+	schedulerRequestTimeout = 10 * time.Second
+	// schedulerMetricsPath is the path the scheduler serves its Prometheus metrics on, next to gRPC.
+	schedulerMetricsPath = "/api/metrics"
+	// stoppedExecutorReason is the reason the scheduler is given for an executor it is told of as stopped.
+	stoppedExecutorReason = "decommissioned by the ballista operator"
+)
+
+// The Prometheus metrics of the scheduler the job and task counts are read from.
+const (
+	pendingTasksMetric  = "pending_task_queue_size"
+	submittedJobsMetric = "job_submitted_total"
+	completedJobsMetric = "job_completed_total"
+	failedJobsMetric    = "job_failed_total"
+	cancelledJobsMetric = "job_cancelled_total"
+)
+
+// grpcSchedulerClient queries schedulers over the SchedulerGrpc service they serve, and reads their metrics
+// from the Prometheus endpoint they serve on the same port.
+type grpcSchedulerClient struct {
+	http *http.Client
+}
+
+// NewSchedulerClient returns a SchedulerClient using the gRPC API and the metrics of the Ballista scheduler.
+func NewSchedulerClient() SchedulerClient {
+	return &grpcSchedulerClient{http: &http.Client{Timeout: schedulerRequestTimeout}}
+}
+
+// NewQueryClient returns a QueryClient using the gRPC API of the Ballista scheduler.
+func NewQueryClient() QueryClient {
+	return &grpcSchedulerClient{http: &http.Client{Timeout: schedulerRequestTimeout}}
+}
+
+// call connects to the scheduler at the endpoint, and calls it through the client within the request timeout.
+func (c *grpcSchedulerClient) call(ctx context.Context, endpoint string, call func(ctx context.Context, client ballista.SchedulerGrpcClient) error) error {
+	ctx, cancel := context.WithTimeout(ctx, schedulerRequestTimeout)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, endpoint, grpc.WithInsecure())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return call(ctx, ballista.NewSchedulerGrpcClient(conn))
+}
+
+// ActiveJobs implements SchedulerClient. The scheduler lists no jobs, so they are counted from its metrics.
+func (c *grpcSchedulerClient) ActiveJobs(ctx context.Context, endpoint string) (int, error) {
+	metrics, err := c.Metrics(ctx, endpoint)
+	return metrics.ActiveJobs, err
+}
+
+// Metrics implements SchedulerMetricsClient.
+func (c *grpcSchedulerClient) Metrics(ctx context.Context, endpoint string) (SchedulerMetrics, error) {
+	values, err := c.prometheusMetrics(ctx, endpoint)
+	if err != nil {
+		return SchedulerMetrics{}, err
+	}
+	active := values[submittedJobsMetric] - values[completedJobsMetric] - values[failedJobsMetric] - values[cancelledJobsMetric]
+	if active < 0 {
+		active = 0
+	}
+	return SchedulerMetrics{
+		PendingTasks: int(values[pendingTasksMetric]),
+		ActiveJobs:   int(active),
+	}, nil
+}
+
+// Executors implements SchedulerClient.
+func (c *grpcSchedulerClient) Executors(ctx context.Context, endpoint string) ([]SchedulerExecutor, error) {
+	var executors []SchedulerExecutor
+	err := c.call(ctx, endpoint, func(ctx context.Context, client ballista.SchedulerGrpcClient) error {
+		result, err := client.GetExecutorsMetadata(ctx, &ballista.GetExecutorMetadataParams{})
+		if err != nil {
+			return err
+		}
+		for _, metadata := range result.Metadata {
+			executors = append(executors, SchedulerExecutor{
+				ID:   metadata.Id,
+				Host: metadata.Host,
+				Port: int32(metadata.Port),
+			})
+		}
+		return nil
+	})
+	return executors, err
+}
+
+// StopExecutor implements SchedulerClient through the ExecutorStopped call executors make when they shut
+// down, after which the scheduler forgets the executor.
+func (c *grpcSchedulerClient) StopExecutor(ctx context.Context, endpoint string, executorID string) error {
+	return c.call(ctx, endpoint, func(ctx context.Context, client ballista.SchedulerGrpcClient) error {
+		_, err := client.ExecutorStopped(ctx, &ballista.ExecutorStoppedParams{
+			ExecutorId: executorID,
+			Reason:     stoppedExecutorReason,
+		})
+		return err
+	})
+}
+
+// SubmitQuery implements QueryClient. The tables are created in the session of the query, which runs in it
+// after them, writing its results to the output if there is one.
+func (c *grpcSchedulerClient) SubmitQuery(ctx context.Context, endpoint string, query QuerySubmission) (string, error) {
+	var jobID string
+	err := c.call(ctx, endpoint, func(ctx context.Context, client ballista.SchedulerGrpcClient) error {
+		sessionID := ""
+		for _, table := range query.Tables {
+			result, err := client.ExecuteQuery(ctx, executeQueryParams(createTableStatement(table), sessionID))
+			if err != nil {
+				return fmt.Errorf("unable to register table %s: %w", table.Name, err)
+			}
+			sessionID = result.SessionId
+		}
+		sql := query.SQL
+		if query.Output != nil {
+			sql = copyToStatement(sql, query.Output)
+		}
+		result, err := client.ExecuteQuery(ctx, executeQueryParams(sql, sessionID))
+		if err != nil {
+			return err
+		}
+		jobID = result.JobId
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if jobID == "" {
+		return "", fmt.Errorf("scheduler %s returned no job ID for the query", endpoint)
+	}
+	return jobID, nil
+}
+
+// executeQueryParams returns the parameters running the SQL statement in the session with the given ID, or
+// in a new one.
+func executeQueryParams(sql string, sessionID string) *ballista.ExecuteQueryParams {
+	params := &ballista.ExecuteQueryParams{Query: &ballista.ExecuteQueryParams_Sql{Sql: sql}}
+	if sessionID != "" {
+		params.OptionalSessionId = &ballista.ExecuteQueryParams_SessionId{SessionId: sessionID}
+	}
+	return params
+}
+
+// JobStatus implements QueryClient.
+func (c *grpcSchedulerClient) JobStatus(ctx context.Context, endpoint string, jobID string) (JobStatus, error) {
+	var status JobStatus
+	err := c.call(ctx, endpoint, func(ctx context.Context, client ballista.SchedulerGrpcClient) error {
+		result, err := client.GetJobStatus(ctx, &ballista.GetJobStatusParams{JobId: jobID})
+		if grpcstatus.Code(err) == codes.NotFound {
+			return errJobNotFound
+		}
+		if err != nil {
+			return err
+		}
+		switch s := result.GetStatus().GetStatus().(type) {
+		case *ballista.JobStatus_Queued:
+			status.Status = "Queued"
+		case *ballista.JobStatus_Running:
+			status.Status = "Running"
+		case *ballista.JobStatus_Failed:
+			status.Status = "Failed"
+			status.Error = s.Failed.Error
+		case *ballista.JobStatus_Successful:
+			status.Status = "Completed"
+		default:
+			return fmt.Errorf("scheduler %s returned no status for job %s", endpoint, jobID)
+		}
+		return nil
+	})
+	return status, err
+}
+
+// CancelJob implements QueryClient.
+func (c *grpcSchedulerClient) CancelJob(ctx context.Context, endpoint string, jobID string) error {
+	return c.call(ctx, endpoint, func(ctx context.Context, client ballista.SchedulerGrpcClient) error {
+		_, err := client.CancelJob(ctx, &ballista.CancelJobParams{JobId: jobID})
+		return err
+	})
+}
+
+// prometheusMetrics reads the metrics the scheduler at the endpoint serves in the Prometheus text format,
+// summing the samples of every metric across their labels.
+func (c *grpcSchedulerClient) prometheusMetrics(ctx context.Context, endpoint string) (map[string]float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s%s", endpoint, schedulerMetricsPath), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scheduler %s responded to GET %s with %s", endpoint, schedulerMetricsPath, resp.Status)
+	}
+
+	values := make(map[string]float64)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// a sample is the metric name, its labels in braces, and its value, maybe followed by a timestamp
+		name := line
+		if i := strings.IndexAny(line, "{ "); i >= 0 {
+			name = line[:i]
+		}
+		rest := strings.TrimPrefix(line, name)
+		if strings.HasPrefix(rest, "{") {
+			end := strings.LastIndex(rest, "}")
+			if end < 0 {
+				continue
+			}
+			rest = rest[end+1:]
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+		values[name] += value
+	}
+	return values, scanner.Err()
+}
+
+// createTableStatement returns the SQL statement registering the table with the session of a query.
+func createTableStatement(table v1.QueryTable) string {
+	var statement strings.Builder
+	fmt.Fprintf(&statement, "CREATE EXTERNAL TABLE %s STORED AS %s", quoteIdentifier(table.Name), strings.ToUpper(string(table.Format)))
+	if len(table.Options) > 0 {
+		keys := make([]string, 0, len(table.Options))
+		for key := range table.Options {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		options := make([]string, 0, len(keys))
+		for _, key := range keys {
+			options = append(options, fmt.Sprintf("%s %s", quoteLiteral(key), quoteLiteral(table.Options[key])))
+		}
+		fmt.Fprintf(&statement, " OPTIONS (%s)", strings.Join(options, ", "))
+	}
+	fmt.Fprintf(&statement, " LOCATION %s", quoteLiteral(table.Location))
+	return statement.String()
+}
+
+// copyToStatement returns the SQL statement writing the results of the query to the output.
+func copyToStatement(sql string, output *v1.QueryOutput) string {
+	format := output.Format
+	if format == "" {
+		format = v1.ParquetTableFormat
+	}
+	sql = strings.TrimRight(strings.TrimSpace(sql), ";")
+	return fmt.Sprintf("COPY (%s) TO %s STORED AS %s", sql, quoteLiteral(output.Location), strings.ToUpper(string(format)))
+}
+
+// quoteIdentifier quotes a SQL identifier.
+func quoteIdentifier(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+// quoteLiteral quotes a SQL string literal.
+func quoteLiteral(literal string) string {
+	return "'" + strings.ReplaceAll(literal, "'", "''") + "'"
+}
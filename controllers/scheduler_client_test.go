@@ -0,0 +1,271 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	v1 "github.com/coderplay/ballista-operator/api/v1"
+	"github.com/coderplay/ballista-operator/pkg/ballista"
+)
+
+// fakeScheduler serves the SchedulerGrpc service over HTTP/2 in cleartext, answering from its fields, and
+// canned Prometheus metrics, as the scheduler does on its single port.
+type fakeScheduler struct {
+	ballista.UnimplementedSchedulerGrpcServer
+	server *httptest.Server
+
+	metrics   string
+	executors []*ballista.ExecutorMetadata
+	jobStatus *ballista.JobStatus
+	// err fails every call
+	err error
+
+	queries   []*ballista.ExecuteQueryParams
+	jobs      []string
+	stopped   []*ballista.ExecutorStoppedParams
+	cancelled []string
+}
+
+func newFakeScheduler() *fakeScheduler {
+	scheduler := &fakeScheduler{}
+	grpcServer := grpc.NewServer()
+	ballista.RegisterSchedulerGrpcServer(grpcServer, scheduler)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.ProtoMajor == 2 && strings.HasPrefix(req.Header.Get("Content-Type"), "application/grpc") {
+			grpcServer.ServeHTTP(w, req)
+			return
+		}
+		if req.URL.Path != schedulerMetricsPath || scheduler.metrics == "" {
+			http.NotFound(w, req)
+			return
+		}
+		_, _ = w.Write([]byte(scheduler.metrics))
+	})
+	scheduler.server = httptest.NewServer(h2c.NewHandler(handler, &http2.Server{}))
+	return scheduler
+}
+
+// endpoint returns the host:port of the fake scheduler.
+func (s *fakeScheduler) endpoint() string {
+	return strings.TrimPrefix(s.server.URL, "http://")
+}
+
+func (s *fakeScheduler) ExecuteQuery(ctx context.Context, params *ballista.ExecuteQueryParams) (*ballista.ExecuteQueryResult, error) {
+	s.queries = append(s.queries, params)
+	return &ballista.ExecuteQueryResult{JobId: "j1", SessionId: "s1"}, s.err
+}
+
+func (s *fakeScheduler) GetJobStatus(ctx context.Context, params *ballista.GetJobStatusParams) (*ballista.GetJobStatusResult, error) {
+	s.jobs = append(s.jobs, params.JobId)
+	return &ballista.GetJobStatusResult{Status: s.jobStatus}, s.err
+}
+
+func (s *fakeScheduler) ExecutorStopped(ctx context.Context, params *ballista.ExecutorStoppedParams) (*ballista.ExecutorStoppedResult, error) {
+	s.stopped = append(s.stopped, params)
+	return &ballista.ExecutorStoppedResult{}, s.err
+}
+
+func (s *fakeScheduler) CancelJob(ctx context.Context, params *ballista.CancelJobParams) (*ballista.CancelJobResult, error) {
+	s.cancelled = append(s.cancelled, params.JobId)
+	return &ballista.CancelJobResult{Cancelled: true}, s.err
+}
+
+func (s *fakeScheduler) GetExecutorsMetadata(ctx context.Context, params *ballista.GetExecutorMetadataParams) (*ballista.GetExecutorMetadataResult, error) {
+	return &ballista.GetExecutorMetadataResult{Metadata: s.executors}, s.err
+}
+
+// fakeSchedulerClient is a SchedulerClient answering from its fields.
+type fakeSchedulerClient struct {
+	activeJobs int
+	metrics    SchedulerMetrics
+	executors  []SchedulerExecutor
+	stopped    []string
+	err        error
+}
+
+func (c *fakeSchedulerClient) ActiveJobs(ctx context.Context, endpoint string) (int, error) {
+	return c.activeJobs, c.err
+}
+
+func (c *fakeSchedulerClient) Metrics(ctx context.Context, endpoint string) (SchedulerMetrics, error) {
+	return c.metrics, c.err
+}
+
+func (c *fakeSchedulerClient) Executors(ctx context.Context, endpoint string) ([]SchedulerExecutor, error) {
+	return c.executors, c.err
+}
+
+func (c *fakeSchedulerClient) StopExecutor(ctx context.Context, endpoint string, executorID string) error {
+	c.stopped = append(c.stopped, executorID)
+	return c.err
+}
+
+// fakeQueryClient is a QueryClient answering from its fields.
+type fakeQueryClient struct {
+	jobID     string
+	status    JobStatus
+	submitted []QuerySubmission
+	cancelled []string
+	err       error
+	// onSubmit is called on every submission, when set
+	onSubmit func()
+}
+
+func (c *fakeQueryClient) SubmitQuery(ctx context.Context, endpoint string, query QuerySubmission) (string, error) {
+	if c.onSubmit != nil {
+		c.onSubmit()
+	}
+	c.submitted = append(c.submitted, query)
+	return c.jobID, c.err
+}
+
+func (c *fakeQueryClient) JobStatus(ctx context.Context, endpoint string, jobID string) (JobStatus, error) {
+	return c.status, c.err
+}
+
+func (c *fakeQueryClient) CancelJob(ctx context.Context, endpoint string, jobID string) error {
+	c.cancelled = append(c.cancelled, jobID)
+	return c.err
+}
+
+var _ = Describe("SchedulerClient", func() {
+	var scheduler *fakeScheduler
+
+	BeforeEach(func() {
+		scheduler = newFakeScheduler()
+	})
+
+	AfterEach(func() {
+		scheduler.server.Close()
+	})
+
+	It("counts the jobs that have not ended", func() {
+		scheduler.metrics = `# TYPE job_submitted_total counter
+job_submitted_total 7
+job_completed_total 3
+job_failed_total{reason="error"} 1
+job_cancelled_total 1
+`
+		jobs, err := NewSchedulerClient().ActiveJobs(context.Background(), scheduler.endpoint())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(jobs).To(Equal(2))
+	})
+
+	It("reads job and task metrics", func() {
+		scheduler.metrics = `# HELP pending_task_queue_size Number of pending tasks
+# TYPE pending_task_queue_size gauge
+pending_task_queue_size 12
+job_submitted_total 1
+`
+		metrics, err := NewSchedulerClient().Metrics(context.Background(), scheduler.endpoint())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(metrics).To(Equal(SchedulerMetrics{PendingTasks: 12, ActiveJobs: 1}))
+	})
+
+	It("lists executors", func() {
+		scheduler.executors = []*ballista.ExecutorMetadata{
+			{Id: "e1", Host: "10.0.0.1", Port: 50051},
+			{Id: "e2", Host: "10.0.0.2", Port: 50051},
+		}
+		executors, err := NewSchedulerClient().Executors(context.Background(), scheduler.endpoint())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(executors).To(Equal([]SchedulerExecutor{
+			{ID: "e1", Host: "10.0.0.1", Port: 50051},
+			{ID: "e2", Host: "10.0.0.2", Port: 50051},
+		}))
+	})
+
+	It("tells the scheduler an executor stopped", func() {
+		Expect(NewSchedulerClient().StopExecutor(context.Background(), scheduler.endpoint(), "e1")).To(Succeed())
+		Expect(scheduler.stopped).To(HaveLen(1))
+		Expect(scheduler.stopped[0].ExecutorId).To(Equal("e1"))
+	})
+
+	It("submits a query in the session of its tables", func() {
+		jobID, err := NewQueryClient().SubmitQuery(context.Background(), scheduler.endpoint(), QuerySubmission{
+			SQL: "SELECT * FROM trips;",
+			Tables: []v1.QueryTable{{
+				Name:     "trips",
+				Format:   v1.CSVTableFormat,
+				Location: "s3://data/trips/",
+				Options:  map[string]string{"has_header": "true", "delimiter": ","},
+			}},
+			Output: &v1.QueryOutput{Location: "s3://results/trips/"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(jobID).To(Equal("j1"))
+		Expect(scheduler.queries).To(HaveLen(2))
+		Expect(scheduler.queries[0].GetSql()).To(Equal(
+			`CREATE EXTERNAL TABLE "trips" STORED AS CSV OPTIONS ('delimiter' ',', 'has_header' 'true') LOCATION 's3://data/trips/'`))
+		Expect(scheduler.queries[0].GetOptionalSessionId()).To(BeNil())
+		Expect(scheduler.queries[1].GetSql()).To(Equal(`COPY (SELECT * FROM trips) TO 's3://results/trips/' STORED AS PARQUET`))
+		Expect(scheduler.queries[1].GetSessionId()).To(Equal("s1"))
+	})
+
+	It("reads the status of a failed job", func() {
+		scheduler.jobStatus = &ballista.JobStatus{Status: &ballista.JobStatus_Failed{
+			Failed: &ballista.FailedJob{Error: "table not found"},
+		}}
+		status, err := NewQueryClient().JobStatus(context.Background(), scheduler.endpoint(), "j1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status).To(Equal(JobStatus{Status: "Failed", Error: "table not found"}))
+		Expect(scheduler.jobs).To(Equal([]string{"j1"}))
+	})
+
+	It("reads the status of a completed job", func() {
+		scheduler.jobStatus = &ballista.JobStatus{Status: &ballista.JobStatus_Successful{
+			Successful: &ballista.SuccessfulJob{},
+		}}
+		status, err := NewQueryClient().JobStatus(context.Background(), scheduler.endpoint(), "j1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status).To(Equal(JobStatus{Status: "Completed"}))
+	})
+
+	It("cancels a job", func() {
+		Expect(NewQueryClient().CancelJob(context.Background(), scheduler.endpoint(), "j1")).To(Succeed())
+		Expect(scheduler.cancelled).To(Equal([]string{"j1"}))
+	})
+
+	It("fails on an error status", func() {
+		scheduler.err = status.Error(codes.Unavailable, "shutting down")
+		_, err := NewQueryClient().JobStatus(context.Background(), scheduler.endpoint(), "j1")
+		Expect(status.Code(err)).To(Equal(codes.Unavailable))
+	})
+
+	It("tells of a job the scheduler does not know", func() {
+		scheduler.err = status.Error(codes.NotFound, "job j1 not found")
+		_, err := NewQueryClient().JobStatus(context.Background(), scheduler.endpoint(), "j1")
+		Expect(err).To(MatchError(errJobNotFound))
+	})
+
+	It("fails without metrics", func() {
+		_, err := NewSchedulerClient().ActiveJobs(context.Background(), scheduler.endpoint())
+		Expect(err).To(HaveOccurred())
+	})
+})
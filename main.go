@@ -25,6 +25,7 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -79,8 +80,11 @@ func main() {
 	}
 
 	if err = (&controllers.BallistaClusterReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		SchedulerClient: controllers.NewSchedulerClient(),
+		Recorder:        mgr.GetEventRecorderFor("ballistacluster-controller"),
+		Clock:           clock.RealClock{},
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "BallistaCluster")
 		os.Exit(1)
@@ -89,6 +93,32 @@ func main() {
 		setupLog.Error(err, "unable to create webhook", "webhook", "BallistaCluster")
 		os.Exit(1)
 	}
+	if err = (&controllers.BallistaQueryReconciler{
+		Client:      mgr.GetClient(),
+		Scheme:      mgr.GetScheme(),
+		QueryClient: controllers.NewQueryClient(),
+		Recorder:    mgr.GetEventRecorderFor("ballistaquery-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "BallistaQuery")
+		os.Exit(1)
+	}
+	if err = (&ballistaminzhouinfov1.BallistaQuery{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "BallistaQuery")
+		os.Exit(1)
+	}
+	if err = (&controllers.BallistaScheduledQueryReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("ballistascheduledquery-controller"),
+		Clock:    clock.RealClock{},
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "BallistaScheduledQuery")
+		os.Exit(1)
+	}
+	if err = (&ballistaminzhouinfov1.BallistaScheduledQuery{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "BallistaScheduledQuery")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
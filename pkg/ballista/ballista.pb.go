@@ -0,0 +1,1400 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// The part of ballista/core/proto/ballista.proto of Apache Arrow Ballista that the operator talks to the
+// scheduler with. Messages keep the names and field numbers of the upstream definitions, and leave out the
+// fields the operator does not read, which the generated code keeps as unknown fields.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.25.0
+// 	protoc        (unknown)
+// source: pkg/ballista/ballista.proto
+
+package ballista
+
+import (
+	proto "github.com/golang/protobuf/proto"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// This is a compile-time assertion that a sufficiently up-to-date version
+// of the legacy proto package is being used.
+const _ = proto.ProtoPackageIsVersion4
+
+type KeyValuePair struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key   string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (x *KeyValuePair) Reset() {
+	*x = KeyValuePair{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_ballista_ballista_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *KeyValuePair) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyValuePair) ProtoMessage() {}
+
+func (x *KeyValuePair) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_ballista_ballista_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyValuePair.ProtoReflect.Descriptor instead.
+func (*KeyValuePair) Descriptor() ([]byte, []int) {
+	return file_pkg_ballista_ballista_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *KeyValuePair) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KeyValuePair) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+type ExecutorMetadata struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id       string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Host     string `protobuf:"bytes,2,opt,name=host,proto3" json:"host,omitempty"`
+	Port     uint32 `protobuf:"varint,3,opt,name=port,proto3" json:"port,omitempty"`
+	GrpcPort uint32 `protobuf:"varint,4,opt,name=grpc_port,json=grpcPort,proto3" json:"grpc_port,omitempty"`
+}
+
+func (x *ExecutorMetadata) Reset() {
+	*x = ExecutorMetadata{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_ballista_ballista_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExecutorMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecutorMetadata) ProtoMessage() {}
+
+func (x *ExecutorMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_ballista_ballista_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecutorMetadata.ProtoReflect.Descriptor instead.
+func (*ExecutorMetadata) Descriptor() ([]byte, []int) {
+	return file_pkg_ballista_ballista_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ExecutorMetadata) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ExecutorMetadata) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *ExecutorMetadata) GetPort() uint32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *ExecutorMetadata) GetGrpcPort() uint32 {
+	if x != nil {
+		return x.GrpcPort
+	}
+	return 0
+}
+
+type ExecuteQueryParams struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Query:
+	//	*ExecuteQueryParams_LogicalPlan
+	//	*ExecuteQueryParams_Sql
+	Query isExecuteQueryParams_Query `protobuf_oneof:"query"`
+	// Types that are assignable to OptionalSessionId:
+	//	*ExecuteQueryParams_SessionId
+	OptionalSessionId isExecuteQueryParams_OptionalSessionId `protobuf_oneof:"optional_session_id"`
+	Settings          []*KeyValuePair                        `protobuf:"bytes,4,rep,name=settings,proto3" json:"settings,omitempty"`
+}
+
+func (x *ExecuteQueryParams) Reset() {
+	*x = ExecuteQueryParams{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_ballista_ballista_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExecuteQueryParams) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecuteQueryParams) ProtoMessage() {}
+
+func (x *ExecuteQueryParams) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_ballista_ballista_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecuteQueryParams.ProtoReflect.Descriptor instead.
+func (*ExecuteQueryParams) Descriptor() ([]byte, []int) {
+	return file_pkg_ballista_ballista_proto_rawDescGZIP(), []int{2}
+}
+
+func (m *ExecuteQueryParams) GetQuery() isExecuteQueryParams_Query {
+	if m != nil {
+		return m.Query
+	}
+	return nil
+}
+
+func (x *ExecuteQueryParams) GetLogicalPlan() []byte {
+	if x, ok := x.GetQuery().(*ExecuteQueryParams_LogicalPlan); ok {
+		return x.LogicalPlan
+	}
+	return nil
+}
+
+func (x *ExecuteQueryParams) GetSql() string {
+	if x, ok := x.GetQuery().(*ExecuteQueryParams_Sql); ok {
+		return x.Sql
+	}
+	return ""
+}
+
+func (m *ExecuteQueryParams) GetOptionalSessionId() isExecuteQueryParams_OptionalSessionId {
+	if m != nil {
+		return m.OptionalSessionId
+	}
+	return nil
+}
+
+func (x *ExecuteQueryParams) GetSessionId() string {
+	if x, ok := x.GetOptionalSessionId().(*ExecuteQueryParams_SessionId); ok {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *ExecuteQueryParams) GetSettings() []*KeyValuePair {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+type isExecuteQueryParams_Query interface {
+	isExecuteQueryParams_Query()
+}
+
+type ExecuteQueryParams_LogicalPlan struct {
+	LogicalPlan []byte `protobuf:"bytes,1,opt,name=logical_plan,json=logicalPlan,proto3,oneof"`
+}
+
+type ExecuteQueryParams_Sql struct {
+	Sql string `protobuf:"bytes,2,opt,name=sql,proto3,oneof"`
+}
+
+func (*ExecuteQueryParams_LogicalPlan) isExecuteQueryParams_Query() {}
+
+func (*ExecuteQueryParams_Sql) isExecuteQueryParams_Query() {}
+
+type isExecuteQueryParams_OptionalSessionId interface {
+	isExecuteQueryParams_OptionalSessionId()
+}
+
+type ExecuteQueryParams_SessionId struct {
+	SessionId string `protobuf:"bytes,3,opt,name=session_id,json=sessionId,proto3,oneof"`
+}
+
+func (*ExecuteQueryParams_SessionId) isExecuteQueryParams_OptionalSessionId() {}
+
+type ExecuteQueryResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	JobId     string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	SessionId string `protobuf:"bytes,2,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+}
+
+func (x *ExecuteQueryResult) Reset() {
+	*x = ExecuteQueryResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_ballista_ballista_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExecuteQueryResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecuteQueryResult) ProtoMessage() {}
+
+func (x *ExecuteQueryResult) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_ballista_ballista_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecuteQueryResult.ProtoReflect.Descriptor instead.
+func (*ExecuteQueryResult) Descriptor() ([]byte, []int) {
+	return file_pkg_ballista_ballista_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ExecuteQueryResult) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *ExecuteQueryResult) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+type GetJobStatusParams struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	JobId string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+}
+
+func (x *GetJobStatusParams) Reset() {
+	*x = GetJobStatusParams{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_ballista_ballista_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetJobStatusParams) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetJobStatusParams) ProtoMessage() {}
+
+func (x *GetJobStatusParams) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_ballista_ballista_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetJobStatusParams.ProtoReflect.Descriptor instead.
+func (*GetJobStatusParams) Descriptor() ([]byte, []int) {
+	return file_pkg_ballista_ballista_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetJobStatusParams) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+type QueuedJob struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *QueuedJob) Reset() {
+	*x = QueuedJob{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_ballista_ballista_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *QueuedJob) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueuedJob) ProtoMessage() {}
+
+func (x *QueuedJob) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_ballista_ballista_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueuedJob.ProtoReflect.Descriptor instead.
+func (*QueuedJob) Descriptor() ([]byte, []int) {
+	return file_pkg_ballista_ballista_proto_rawDescGZIP(), []int{5}
+}
+
+type RunningJob struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *RunningJob) Reset() {
+	*x = RunningJob{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_ballista_ballista_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RunningJob) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunningJob) ProtoMessage() {}
+
+func (x *RunningJob) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_ballista_ballista_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunningJob.ProtoReflect.Descriptor instead.
+func (*RunningJob) Descriptor() ([]byte, []int) {
+	return file_pkg_ballista_ballista_proto_rawDescGZIP(), []int{6}
+}
+
+type FailedJob struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Error string `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *FailedJob) Reset() {
+	*x = FailedJob{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_ballista_ballista_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FailedJob) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FailedJob) ProtoMessage() {}
+
+func (x *FailedJob) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_ballista_ballista_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FailedJob.ProtoReflect.Descriptor instead.
+func (*FailedJob) Descriptor() ([]byte, []int) {
+	return file_pkg_ballista_ballista_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FailedJob) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type SuccessfulJob struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *SuccessfulJob) Reset() {
+	*x = SuccessfulJob{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_ballista_ballista_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SuccessfulJob) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SuccessfulJob) ProtoMessage() {}
+
+func (x *SuccessfulJob) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_ballista_ballista_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SuccessfulJob.ProtoReflect.Descriptor instead.
+func (*SuccessfulJob) Descriptor() ([]byte, []int) {
+	return file_pkg_ballista_ballista_proto_rawDescGZIP(), []int{8}
+}
+
+type JobStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Status:
+	//	*JobStatus_Queued
+	//	*JobStatus_Running
+	//	*JobStatus_Failed
+	//	*JobStatus_Successful
+	Status isJobStatus_Status `protobuf_oneof:"status"`
+}
+
+func (x *JobStatus) Reset() {
+	*x = JobStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_ballista_ballista_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *JobStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobStatus) ProtoMessage() {}
+
+func (x *JobStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_ballista_ballista_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobStatus.ProtoReflect.Descriptor instead.
+func (*JobStatus) Descriptor() ([]byte, []int) {
+	return file_pkg_ballista_ballista_proto_rawDescGZIP(), []int{9}
+}
+
+func (m *JobStatus) GetStatus() isJobStatus_Status {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+func (x *JobStatus) GetQueued() *QueuedJob {
+	if x, ok := x.GetStatus().(*JobStatus_Queued); ok {
+		return x.Queued
+	}
+	return nil
+}
+
+func (x *JobStatus) GetRunning() *RunningJob {
+	if x, ok := x.GetStatus().(*JobStatus_Running); ok {
+		return x.Running
+	}
+	return nil
+}
+
+func (x *JobStatus) GetFailed() *FailedJob {
+	if x, ok := x.GetStatus().(*JobStatus_Failed); ok {
+		return x.Failed
+	}
+	return nil
+}
+
+func (x *JobStatus) GetSuccessful() *SuccessfulJob {
+	if x, ok := x.GetStatus().(*JobStatus_Successful); ok {
+		return x.Successful
+	}
+	return nil
+}
+
+type isJobStatus_Status interface {
+	isJobStatus_Status()
+}
+
+type JobStatus_Queued struct {
+	Queued *QueuedJob `protobuf:"bytes,1,opt,name=queued,proto3,oneof"`
+}
+
+type JobStatus_Running struct {
+	Running *RunningJob `protobuf:"bytes,2,opt,name=running,proto3,oneof"`
+}
+
+type JobStatus_Failed struct {
+	Failed *FailedJob `protobuf:"bytes,3,opt,name=failed,proto3,oneof"`
+}
+
+type JobStatus_Successful struct {
+	Successful *SuccessfulJob `protobuf:"bytes,4,opt,name=successful,proto3,oneof"`
+}
+
+func (*JobStatus_Queued) isJobStatus_Status() {}
+
+func (*JobStatus_Running) isJobStatus_Status() {}
+
+func (*JobStatus_Failed) isJobStatus_Status() {}
+
+func (*JobStatus_Successful) isJobStatus_Status() {}
+
+type GetJobStatusResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status *JobStatus `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+}
+
+func (x *GetJobStatusResult) Reset() {
+	*x = GetJobStatusResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_ballista_ballista_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetJobStatusResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetJobStatusResult) ProtoMessage() {}
+
+func (x *GetJobStatusResult) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_ballista_ballista_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetJobStatusResult.ProtoReflect.Descriptor instead.
+func (*GetJobStatusResult) Descriptor() ([]byte, []int) {
+	return file_pkg_ballista_ballista_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetJobStatusResult) GetStatus() *JobStatus {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+type ExecutorStoppedParams struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ExecutorId string `protobuf:"bytes,1,opt,name=executor_id,json=executorId,proto3" json:"executor_id,omitempty"`
+	// stop reason
+	Reason string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+}
+
+func (x *ExecutorStoppedParams) Reset() {
+	*x = ExecutorStoppedParams{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_ballista_ballista_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExecutorStoppedParams) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecutorStoppedParams) ProtoMessage() {}
+
+func (x *ExecutorStoppedParams) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_ballista_ballista_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecutorStoppedParams.ProtoReflect.Descriptor instead.
+func (*ExecutorStoppedParams) Descriptor() ([]byte, []int) {
+	return file_pkg_ballista_ballista_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ExecutorStoppedParams) GetExecutorId() string {
+	if x != nil {
+		return x.ExecutorId
+	}
+	return ""
+}
+
+func (x *ExecutorStoppedParams) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type ExecutorStoppedResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ExecutorStoppedResult) Reset() {
+	*x = ExecutorStoppedResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_ballista_ballista_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExecutorStoppedResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecutorStoppedResult) ProtoMessage() {}
+
+func (x *ExecutorStoppedResult) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_ballista_ballista_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecutorStoppedResult.ProtoReflect.Descriptor instead.
+func (*ExecutorStoppedResult) Descriptor() ([]byte, []int) {
+	return file_pkg_ballista_ballista_proto_rawDescGZIP(), []int{12}
+}
+
+type CancelJobParams struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	JobId string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+}
+
+func (x *CancelJobParams) Reset() {
+	*x = CancelJobParams{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_ballista_ballista_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CancelJobParams) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelJobParams) ProtoMessage() {}
+
+func (x *CancelJobParams) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_ballista_ballista_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelJobParams.ProtoReflect.Descriptor instead.
+func (*CancelJobParams) Descriptor() ([]byte, []int) {
+	return file_pkg_ballista_ballista_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *CancelJobParams) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+type CancelJobResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Cancelled bool `protobuf:"varint,1,opt,name=cancelled,proto3" json:"cancelled,omitempty"`
+}
+
+func (x *CancelJobResult) Reset() {
+	*x = CancelJobResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_ballista_ballista_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CancelJobResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelJobResult) ProtoMessage() {}
+
+func (x *CancelJobResult) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_ballista_ballista_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelJobResult.ProtoReflect.Descriptor instead.
+func (*CancelJobResult) Descriptor() ([]byte, []int) {
+	return file_pkg_ballista_ballista_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *CancelJobResult) GetCancelled() bool {
+	if x != nil {
+		return x.Cancelled
+	}
+	return false
+}
+
+type GetExecutorMetadataParams struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetExecutorMetadataParams) Reset() {
+	*x = GetExecutorMetadataParams{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_ballista_ballista_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetExecutorMetadataParams) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetExecutorMetadataParams) ProtoMessage() {}
+
+func (x *GetExecutorMetadataParams) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_ballista_ballista_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetExecutorMetadataParams.ProtoReflect.Descriptor instead.
+func (*GetExecutorMetadataParams) Descriptor() ([]byte, []int) {
+	return file_pkg_ballista_ballista_proto_rawDescGZIP(), []int{15}
+}
+
+type GetExecutorMetadataResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Metadata []*ExecutorMetadata `protobuf:"bytes,1,rep,name=metadata,proto3" json:"metadata,omitempty"`
+}
+
+func (x *GetExecutorMetadataResult) Reset() {
+	*x = GetExecutorMetadataResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_ballista_ballista_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetExecutorMetadataResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetExecutorMetadataResult) ProtoMessage() {}
+
+func (x *GetExecutorMetadataResult) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_ballista_ballista_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetExecutorMetadataResult.ProtoReflect.Descriptor instead.
+func (*GetExecutorMetadataResult) Descriptor() ([]byte, []int) {
+	return file_pkg_ballista_ballista_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *GetExecutorMetadataResult) GetMetadata() []*ExecutorMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+var File_pkg_ballista_ballista_proto protoreflect.FileDescriptor
+
+var file_pkg_ballista_ballista_proto_rawDesc = []byte{
+	0x0a, 0x1b, 0x70, 0x6b, 0x67, 0x2f, 0x62, 0x61, 0x6c, 0x6c, 0x69, 0x73, 0x74, 0x61, 0x2f, 0x62,
+	0x61, 0x6c, 0x6c, 0x69, 0x73, 0x74, 0x61, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x11, 0x62,
+	0x61, 0x6c, 0x6c, 0x69, 0x73, 0x74, 0x61, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x22, 0x36, 0x0a, 0x0c, 0x4b, 0x65, 0x79, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x50, 0x61, 0x69, 0x72,
+	0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b,
+	0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x67, 0x0a, 0x10, 0x45, 0x78, 0x65, 0x63,
+	0x75, 0x74, 0x6f, 0x72, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x0e, 0x0a, 0x02,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04,
+	0x68, 0x6f, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68, 0x6f, 0x73, 0x74,
+	0x12, 0x12, 0x0a, 0x04, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04,
+	0x70, 0x6f, 0x72, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x70, 0x6f, 0x72,
+	0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x67, 0x72, 0x70, 0x63, 0x50, 0x6f, 0x72,
+	0x74, 0x22, 0xcb, 0x01, 0x0a, 0x12, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x65, 0x51, 0x75, 0x65,
+	0x72, 0x79, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x12, 0x23, 0x0a, 0x0c, 0x6c, 0x6f, 0x67, 0x69,
+	0x63, 0x61, 0x6c, 0x5f, 0x70, 0x6c, 0x61, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x48, 0x00,
+	0x52, 0x0b, 0x6c, 0x6f, 0x67, 0x69, 0x63, 0x61, 0x6c, 0x50, 0x6c, 0x61, 0x6e, 0x12, 0x12, 0x0a,
+	0x03, 0x73, 0x71, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x03, 0x73, 0x71,
+	0x6c, 0x12, 0x1f, 0x0a, 0x0a, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x48, 0x01, 0x52, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e,
+	0x49, 0x64, 0x12, 0x3b, 0x0a, 0x08, 0x73, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x04,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x62, 0x61, 0x6c, 0x6c, 0x69, 0x73, 0x74, 0x61, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4b, 0x65, 0x79, 0x56, 0x61, 0x6c, 0x75,
+	0x65, 0x50, 0x61, 0x69, 0x72, 0x52, 0x08, 0x73, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x42,
+	0x07, 0x0a, 0x05, 0x71, 0x75, 0x65, 0x72, 0x79, 0x42, 0x15, 0x0a, 0x13, 0x6f, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x61, 0x6c, 0x5f, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x22,
+	0x4a, 0x0a, 0x12, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x65, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x15, 0x0a, 0x06, 0x6a, 0x6f, 0x62, 0x5f, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a,
+	0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x22, 0x2b, 0x0a, 0x12, 0x47,
+	0x65, 0x74, 0x4a, 0x6f, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x50, 0x61, 0x72, 0x61, 0x6d,
+	0x73, 0x12, 0x15, 0x0a, 0x06, 0x6a, 0x6f, 0x62, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x64, 0x22, 0x0b, 0x0a, 0x09, 0x51, 0x75, 0x65, 0x75,
+	0x65, 0x64, 0x4a, 0x6f, 0x62, 0x22, 0x0c, 0x0a, 0x0a, 0x52, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67,
+	0x4a, 0x6f, 0x62, 0x22, 0x21, 0x0a, 0x09, 0x46, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x4a, 0x6f, 0x62,
+	0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x0f, 0x0a, 0x0d, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73,
+	0x73, 0x66, 0x75, 0x6c, 0x4a, 0x6f, 0x62, 0x22, 0x84, 0x02, 0x0a, 0x09, 0x4a, 0x6f, 0x62, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x36, 0x0a, 0x06, 0x71, 0x75, 0x65, 0x75, 0x65, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x62, 0x61, 0x6c, 0x6c, 0x69, 0x73, 0x74, 0x61,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x51, 0x75, 0x65, 0x75, 0x65, 0x64,
+	0x4a, 0x6f, 0x62, 0x48, 0x00, 0x52, 0x06, 0x71, 0x75, 0x65, 0x75, 0x65, 0x64, 0x12, 0x39, 0x0a,
+	0x07, 0x72, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d,
+	0x2e, 0x62, 0x61, 0x6c, 0x6c, 0x69, 0x73, 0x74, 0x61, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x52, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x4a, 0x6f, 0x62, 0x48, 0x00, 0x52,
+	0x07, 0x72, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x12, 0x36, 0x0a, 0x06, 0x66, 0x61, 0x69, 0x6c,
+	0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x62, 0x61, 0x6c, 0x6c, 0x69,
+	0x73, 0x74, 0x61, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x61, 0x69,
+	0x6c, 0x65, 0x64, 0x4a, 0x6f, 0x62, 0x48, 0x00, 0x52, 0x06, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64,
+	0x12, 0x42, 0x0a, 0x0a, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x66, 0x75, 0x6c, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x62, 0x61, 0x6c, 0x6c, 0x69, 0x73, 0x74, 0x61, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73,
+	0x66, 0x75, 0x6c, 0x4a, 0x6f, 0x62, 0x48, 0x00, 0x52, 0x0a, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73,
+	0x73, 0x66, 0x75, 0x6c, 0x42, 0x08, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x4a,
+	0x0a, 0x12, 0x47, 0x65, 0x74, 0x4a, 0x6f, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65,
+	0x73, 0x75, 0x6c, 0x74, 0x12, 0x34, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x62, 0x61, 0x6c, 0x6c, 0x69, 0x73, 0x74, 0x61, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4a, 0x6f, 0x62, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x50, 0x0a, 0x15, 0x45, 0x78,
+	0x65, 0x63, 0x75, 0x74, 0x6f, 0x72, 0x53, 0x74, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x50, 0x61, 0x72,
+	0x61, 0x6d, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x65, 0x78, 0x65, 0x63, 0x75, 0x74, 0x6f, 0x72, 0x5f,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x65, 0x78, 0x65, 0x63, 0x75, 0x74,
+	0x6f, 0x72, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x22, 0x17, 0x0a, 0x15,
+	0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x6f, 0x72, 0x53, 0x74, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x52,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x28, 0x0a, 0x0f, 0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x4a,
+	0x6f, 0x62, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x12, 0x15, 0x0a, 0x06, 0x6a, 0x6f, 0x62, 0x5f,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x64, 0x22,
+	0x2f, 0x0a, 0x0f, 0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x4a, 0x6f, 0x62, 0x52, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x6c, 0x65, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x63, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x6c, 0x65, 0x64,
+	0x22, 0x1b, 0x0a, 0x19, 0x47, 0x65, 0x74, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x6f, 0x72, 0x4d,
+	0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x22, 0x5c, 0x0a,
+	0x19, 0x47, 0x65, 0x74, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x6f, 0x72, 0x4d, 0x65, 0x74, 0x61,
+	0x64, 0x61, 0x74, 0x61, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x3f, 0x0a, 0x08, 0x6d, 0x65,
+	0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x62,
+	0x61, 0x6c, 0x6c, 0x69, 0x73, 0x74, 0x61, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x6f, 0x72, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74,
+	0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x32, 0x85, 0x04, 0x0a, 0x0d,
+	0x53, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x72, 0x47, 0x72, 0x70, 0x63, 0x12, 0x5e, 0x0a,
+	0x0c, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x65, 0x51, 0x75, 0x65, 0x72, 0x79, 0x12, 0x25, 0x2e,
+	0x62, 0x61, 0x6c, 0x6c, 0x69, 0x73, 0x74, 0x61, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x65, 0x51, 0x75, 0x65, 0x72, 0x79, 0x50, 0x61,
+	0x72, 0x61, 0x6d, 0x73, 0x1a, 0x25, 0x2e, 0x62, 0x61, 0x6c, 0x6c, 0x69, 0x73, 0x74, 0x61, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x65,
+	0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x00, 0x12, 0x5e, 0x0a,
+	0x0c, 0x47, 0x65, 0x74, 0x4a, 0x6f, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x25, 0x2e,
+	0x62, 0x61, 0x6c, 0x6c, 0x69, 0x73, 0x74, 0x61, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x47, 0x65, 0x74, 0x4a, 0x6f, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x50, 0x61,
+	0x72, 0x61, 0x6d, 0x73, 0x1a, 0x25, 0x2e, 0x62, 0x61, 0x6c, 0x6c, 0x69, 0x73, 0x74, 0x61, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x47, 0x65, 0x74, 0x4a, 0x6f, 0x62, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x00, 0x12, 0x67, 0x0a,
+	0x0f, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x6f, 0x72, 0x53, 0x74, 0x6f, 0x70, 0x70, 0x65, 0x64,
+	0x12, 0x28, 0x2e, 0x62, 0x61, 0x6c, 0x6c, 0x69, 0x73, 0x74, 0x61, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x6f, 0x72, 0x53, 0x74, 0x6f,
+	0x70, 0x70, 0x65, 0x64, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x1a, 0x28, 0x2e, 0x62, 0x61, 0x6c,
+	0x6c, 0x69, 0x73, 0x74, 0x61, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45,
+	0x78, 0x65, 0x63, 0x75, 0x74, 0x6f, 0x72, 0x53, 0x74, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x52, 0x65,
+	0x73, 0x75, 0x6c, 0x74, 0x22, 0x00, 0x12, 0x55, 0x0a, 0x09, 0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c,
+	0x4a, 0x6f, 0x62, 0x12, 0x22, 0x2e, 0x62, 0x61, 0x6c, 0x6c, 0x69, 0x73, 0x74, 0x61, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x4a, 0x6f,
+	0x62, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x1a, 0x22, 0x2e, 0x62, 0x61, 0x6c, 0x6c, 0x69, 0x73,
+	0x74, 0x61, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x43, 0x61, 0x6e, 0x63,
+	0x65, 0x6c, 0x4a, 0x6f, 0x62, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x00, 0x12, 0x74, 0x0a,
+	0x14, 0x47, 0x65, 0x74, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x6f, 0x72, 0x73, 0x4d, 0x65, 0x74,
+	0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x2c, 0x2e, 0x62, 0x61, 0x6c, 0x6c, 0x69, 0x73, 0x74, 0x61,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x47, 0x65, 0x74, 0x45, 0x78, 0x65,
+	0x63, 0x75, 0x74, 0x6f, 0x72, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x50, 0x61, 0x72,
+	0x61, 0x6d, 0x73, 0x1a, 0x2c, 0x2e, 0x62, 0x61, 0x6c, 0x6c, 0x69, 0x73, 0x74, 0x61, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x47, 0x65, 0x74, 0x45, 0x78, 0x65, 0x63, 0x75,
+	0x74, 0x6f, 0x72, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x65, 0x73, 0x75, 0x6c,
+	0x74, 0x22, 0x00, 0x42, 0x35, 0x5a, 0x33, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
+	0x6d, 0x2f, 0x63, 0x6f, 0x64, 0x65, 0x72, 0x70, 0x6c, 0x61, 0x79, 0x2f, 0x62, 0x61, 0x6c, 0x6c,
+	0x69, 0x73, 0x74, 0x61, 0x2d, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x2f, 0x70, 0x6b,
+	0x67, 0x2f, 0x62, 0x61, 0x6c, 0x6c, 0x69, 0x73, 0x74, 0x61, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
+}
+
+var (
+	file_pkg_ballista_ballista_proto_rawDescOnce sync.Once
+	file_pkg_ballista_ballista_proto_rawDescData = file_pkg_ballista_ballista_proto_rawDesc
+)
+
+func file_pkg_ballista_ballista_proto_rawDescGZIP() []byte {
+	file_pkg_ballista_ballista_proto_rawDescOnce.Do(func() {
+		file_pkg_ballista_ballista_proto_rawDescData = protoimpl.X.CompressGZIP(file_pkg_ballista_ballista_proto_rawDescData)
+	})
+	return file_pkg_ballista_ballista_proto_rawDescData
+}
+
+var file_pkg_ballista_ballista_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_pkg_ballista_ballista_proto_goTypes = []interface{}{
+	(*KeyValuePair)(nil),              // 0: ballista.protobuf.KeyValuePair
+	(*ExecutorMetadata)(nil),          // 1: ballista.protobuf.ExecutorMetadata
+	(*ExecuteQueryParams)(nil),        // 2: ballista.protobuf.ExecuteQueryParams
+	(*ExecuteQueryResult)(nil),        // 3: ballista.protobuf.ExecuteQueryResult
+	(*GetJobStatusParams)(nil),        // 4: ballista.protobuf.GetJobStatusParams
+	(*QueuedJob)(nil),                 // 5: ballista.protobuf.QueuedJob
+	(*RunningJob)(nil),                // 6: ballista.protobuf.RunningJob
+	(*FailedJob)(nil),                 // 7: ballista.protobuf.FailedJob
+	(*SuccessfulJob)(nil),             // 8: ballista.protobuf.SuccessfulJob
+	(*JobStatus)(nil),                 // 9: ballista.protobuf.JobStatus
+	(*GetJobStatusResult)(nil),        // 10: ballista.protobuf.GetJobStatusResult
+	(*ExecutorStoppedParams)(nil),     // 11: ballista.protobuf.ExecutorStoppedParams
+	(*ExecutorStoppedResult)(nil),     // 12: ballista.protobuf.ExecutorStoppedResult
+	(*CancelJobParams)(nil),           // 13: ballista.protobuf.CancelJobParams
+	(*CancelJobResult)(nil),           // 14: ballista.protobuf.CancelJobResult
+	(*GetExecutorMetadataParams)(nil), // 15: ballista.protobuf.GetExecutorMetadataParams
+	(*GetExecutorMetadataResult)(nil), // 16: ballista.protobuf.GetExecutorMetadataResult
+}
+var file_pkg_ballista_ballista_proto_depIdxs = []int32{
+	0,  // 0: ballista.protobuf.ExecuteQueryParams.settings:type_name -> ballista.protobuf.KeyValuePair
+	5,  // 1: ballista.protobuf.JobStatus.queued:type_name -> ballista.protobuf.QueuedJob
+	6,  // 2: ballista.protobuf.JobStatus.running:type_name -> ballista.protobuf.RunningJob
+	7,  // 3: ballista.protobuf.JobStatus.failed:type_name -> ballista.protobuf.FailedJob
+	8,  // 4: ballista.protobuf.JobStatus.successful:type_name -> ballista.protobuf.SuccessfulJob
+	9,  // 5: ballista.protobuf.GetJobStatusResult.status:type_name -> ballista.protobuf.JobStatus
+	1,  // 6: ballista.protobuf.GetExecutorMetadataResult.metadata:type_name -> ballista.protobuf.ExecutorMetadata
+	2,  // 7: ballista.protobuf.SchedulerGrpc.ExecuteQuery:input_type -> ballista.protobuf.ExecuteQueryParams
+	4,  // 8: ballista.protobuf.SchedulerGrpc.GetJobStatus:input_type -> ballista.protobuf.GetJobStatusParams
+	11, // 9: ballista.protobuf.SchedulerGrpc.ExecutorStopped:input_type -> ballista.protobuf.ExecutorStoppedParams
+	13, // 10: ballista.protobuf.SchedulerGrpc.CancelJob:input_type -> ballista.protobuf.CancelJobParams
+	15, // 11: ballista.protobuf.SchedulerGrpc.GetExecutorsMetadata:input_type -> ballista.protobuf.GetExecutorMetadataParams
+	3,  // 12: ballista.protobuf.SchedulerGrpc.ExecuteQuery:output_type -> ballista.protobuf.ExecuteQueryResult
+	10, // 13: ballista.protobuf.SchedulerGrpc.GetJobStatus:output_type -> ballista.protobuf.GetJobStatusResult
+	12, // 14: ballista.protobuf.SchedulerGrpc.ExecutorStopped:output_type -> ballista.protobuf.ExecutorStoppedResult
+	14, // 15: ballista.protobuf.SchedulerGrpc.CancelJob:output_type -> ballista.protobuf.CancelJobResult
+	16, // 16: ballista.protobuf.SchedulerGrpc.GetExecutorsMetadata:output_type -> ballista.protobuf.GetExecutorMetadataResult
+	12, // [12:17] is the sub-list for method output_type
+	7,  // [7:12] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_pkg_ballista_ballista_proto_init() }
+func file_pkg_ballista_ballista_proto_init() {
+	if File_pkg_ballista_ballista_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_pkg_ballista_ballista_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KeyValuePair); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_ballista_ballista_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExecutorMetadata); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_ballista_ballista_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExecuteQueryParams); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_ballista_ballista_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExecuteQueryResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_ballista_ballista_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetJobStatusParams); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_ballista_ballista_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*QueuedJob); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_ballista_ballista_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RunningJob); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_ballista_ballista_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FailedJob); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_ballista_ballista_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SuccessfulJob); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_ballista_ballista_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*JobStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_ballista_ballista_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetJobStatusResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_ballista_ballista_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExecutorStoppedParams); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_ballista_ballista_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExecutorStoppedResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_ballista_ballista_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CancelJobParams); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_ballista_ballista_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CancelJobResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_ballista_ballista_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetExecutorMetadataParams); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_ballista_ballista_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetExecutorMetadataResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_pkg_ballista_ballista_proto_msgTypes[2].OneofWrappers = []interface{}{
+		(*ExecuteQueryParams_LogicalPlan)(nil),
+		(*ExecuteQueryParams_Sql)(nil),
+		(*ExecuteQueryParams_SessionId)(nil),
+	}
+	file_pkg_ballista_ballista_proto_msgTypes[9].OneofWrappers = []interface{}{
+		(*JobStatus_Queued)(nil),
+		(*JobStatus_Running)(nil),
+		(*JobStatus_Failed)(nil),
+		(*JobStatus_Successful)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_pkg_ballista_ballista_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   17,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkg_ballista_ballista_proto_goTypes,
+		DependencyIndexes: file_pkg_ballista_ballista_proto_depIdxs,
+		MessageInfos:      file_pkg_ballista_ballista_proto_msgTypes,
+	}.Build()
+	File_pkg_ballista_ballista_proto = out.File
+	file_pkg_ballista_ballista_proto_rawDesc = nil
+	file_pkg_ballista_ballista_proto_goTypes = nil
+	file_pkg_ballista_ballista_proto_depIdxs = nil
+}
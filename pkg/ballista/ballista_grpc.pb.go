@@ -0,0 +1,247 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package ballista
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// SchedulerGrpcClient is the client API for SchedulerGrpc service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SchedulerGrpcClient interface {
+	ExecuteQuery(ctx context.Context, in *ExecuteQueryParams, opts ...grpc.CallOption) (*ExecuteQueryResult, error)
+	GetJobStatus(ctx context.Context, in *GetJobStatusParams, opts ...grpc.CallOption) (*GetJobStatusResult, error)
+	// Used by Executor to tell Scheduler it is stopped.
+	ExecutorStopped(ctx context.Context, in *ExecutorStoppedParams, opts ...grpc.CallOption) (*ExecutorStoppedResult, error)
+	CancelJob(ctx context.Context, in *CancelJobParams, opts ...grpc.CallOption) (*CancelJobResult, error)
+	GetExecutorsMetadata(ctx context.Context, in *GetExecutorMetadataParams, opts ...grpc.CallOption) (*GetExecutorMetadataResult, error)
+}
+
+type schedulerGrpcClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSchedulerGrpcClient(cc grpc.ClientConnInterface) SchedulerGrpcClient {
+	return &schedulerGrpcClient{cc}
+}
+
+func (c *schedulerGrpcClient) ExecuteQuery(ctx context.Context, in *ExecuteQueryParams, opts ...grpc.CallOption) (*ExecuteQueryResult, error) {
+	out := new(ExecuteQueryResult)
+	err := c.cc.Invoke(ctx, "/ballista.protobuf.SchedulerGrpc/ExecuteQuery", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *schedulerGrpcClient) GetJobStatus(ctx context.Context, in *GetJobStatusParams, opts ...grpc.CallOption) (*GetJobStatusResult, error) {
+	out := new(GetJobStatusResult)
+	err := c.cc.Invoke(ctx, "/ballista.protobuf.SchedulerGrpc/GetJobStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *schedulerGrpcClient) ExecutorStopped(ctx context.Context, in *ExecutorStoppedParams, opts ...grpc.CallOption) (*ExecutorStoppedResult, error) {
+	out := new(ExecutorStoppedResult)
+	err := c.cc.Invoke(ctx, "/ballista.protobuf.SchedulerGrpc/ExecutorStopped", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *schedulerGrpcClient) CancelJob(ctx context.Context, in *CancelJobParams, opts ...grpc.CallOption) (*CancelJobResult, error) {
+	out := new(CancelJobResult)
+	err := c.cc.Invoke(ctx, "/ballista.protobuf.SchedulerGrpc/CancelJob", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *schedulerGrpcClient) GetExecutorsMetadata(ctx context.Context, in *GetExecutorMetadataParams, opts ...grpc.CallOption) (*GetExecutorMetadataResult, error) {
+	out := new(GetExecutorMetadataResult)
+	err := c.cc.Invoke(ctx, "/ballista.protobuf.SchedulerGrpc/GetExecutorsMetadata", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SchedulerGrpcServer is the server API for SchedulerGrpc service.
+// All implementations must embed UnimplementedSchedulerGrpcServer
+// for forward compatibility
+type SchedulerGrpcServer interface {
+	ExecuteQuery(context.Context, *ExecuteQueryParams) (*ExecuteQueryResult, error)
+	GetJobStatus(context.Context, *GetJobStatusParams) (*GetJobStatusResult, error)
+	// Used by Executor to tell Scheduler it is stopped.
+	ExecutorStopped(context.Context, *ExecutorStoppedParams) (*ExecutorStoppedResult, error)
+	CancelJob(context.Context, *CancelJobParams) (*CancelJobResult, error)
+	GetExecutorsMetadata(context.Context, *GetExecutorMetadataParams) (*GetExecutorMetadataResult, error)
+	mustEmbedUnimplementedSchedulerGrpcServer()
+}
+
+// UnimplementedSchedulerGrpcServer must be embedded to have forward compatible implementations.
+type UnimplementedSchedulerGrpcServer struct {
+}
+
+func (UnimplementedSchedulerGrpcServer) ExecuteQuery(context.Context, *ExecuteQueryParams) (*ExecuteQueryResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExecuteQuery not implemented")
+}
+func (UnimplementedSchedulerGrpcServer) GetJobStatus(context.Context, *GetJobStatusParams) (*GetJobStatusResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetJobStatus not implemented")
+}
+func (UnimplementedSchedulerGrpcServer) ExecutorStopped(context.Context, *ExecutorStoppedParams) (*ExecutorStoppedResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExecutorStopped not implemented")
+}
+func (UnimplementedSchedulerGrpcServer) CancelJob(context.Context, *CancelJobParams) (*CancelJobResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelJob not implemented")
+}
+func (UnimplementedSchedulerGrpcServer) GetExecutorsMetadata(context.Context, *GetExecutorMetadataParams) (*GetExecutorMetadataResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetExecutorsMetadata not implemented")
+}
+func (UnimplementedSchedulerGrpcServer) mustEmbedUnimplementedSchedulerGrpcServer() {}
+
+// UnsafeSchedulerGrpcServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SchedulerGrpcServer will
+// result in compilation errors.
+type UnsafeSchedulerGrpcServer interface {
+	mustEmbedUnimplementedSchedulerGrpcServer()
+}
+
+func RegisterSchedulerGrpcServer(s grpc.ServiceRegistrar, srv SchedulerGrpcServer) {
+	s.RegisterService(&SchedulerGrpc_ServiceDesc, srv)
+}
+
+func _SchedulerGrpc_ExecuteQuery_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExecuteQueryParams)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SchedulerGrpcServer).ExecuteQuery(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ballista.protobuf.SchedulerGrpc/ExecuteQuery",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SchedulerGrpcServer).ExecuteQuery(ctx, req.(*ExecuteQueryParams))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SchedulerGrpc_GetJobStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetJobStatusParams)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SchedulerGrpcServer).GetJobStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ballista.protobuf.SchedulerGrpc/GetJobStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SchedulerGrpcServer).GetJobStatus(ctx, req.(*GetJobStatusParams))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SchedulerGrpc_ExecutorStopped_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExecutorStoppedParams)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SchedulerGrpcServer).ExecutorStopped(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ballista.protobuf.SchedulerGrpc/ExecutorStopped",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SchedulerGrpcServer).ExecutorStopped(ctx, req.(*ExecutorStoppedParams))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SchedulerGrpc_CancelJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelJobParams)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SchedulerGrpcServer).CancelJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ballista.protobuf.SchedulerGrpc/CancelJob",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SchedulerGrpcServer).CancelJob(ctx, req.(*CancelJobParams))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SchedulerGrpc_GetExecutorsMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetExecutorMetadataParams)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SchedulerGrpcServer).GetExecutorsMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ballista.protobuf.SchedulerGrpc/GetExecutorsMetadata",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SchedulerGrpcServer).GetExecutorsMetadata(ctx, req.(*GetExecutorMetadataParams))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SchedulerGrpc_ServiceDesc is the grpc.ServiceDesc for SchedulerGrpc service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SchedulerGrpc_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ballista.protobuf.SchedulerGrpc",
+	HandlerType: (*SchedulerGrpcServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ExecuteQuery",
+			Handler:    _SchedulerGrpc_ExecuteQuery_Handler,
+		},
+		{
+			MethodName: "GetJobStatus",
+			Handler:    _SchedulerGrpc_GetJobStatus_Handler,
+		},
+		{
+			MethodName: "ExecutorStopped",
+			Handler:    _SchedulerGrpc_ExecutorStopped_Handler,
+		},
+		{
+			MethodName: "CancelJob",
+			Handler:    _SchedulerGrpc_CancelJob_Handler,
+		},
+		{
+			MethodName: "GetExecutorsMetadata",
+			Handler:    _SchedulerGrpc_GetExecutorsMetadata_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkg/ballista/ballista.proto",
+}